@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/gryd-database/platform-poc/configuration"
+	"github.com/gryd-database/platform-poc/pkg/node"
+	"github.com/gryd-database/platform-poc/pkg/signer"
+)
+
+// keygen creates a new node key in an encrypted V3 keystore file, the password is read the same way the node reads it
+func keygen(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	out := flags.String("out", "./keystore/node.json", "path of the keystore file to create")
+	passwordFile := flags.String("password-file", "", "file holding the keystore password")
+	passwordEnv := flags.String("password-env", node.DefaultPasswordEnv, "environment variable holding the keystore password, used without a password file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	password, err := node.KeystorePassword(configuration.Crypto{PasswordFile: *passwordFile, PasswordEnv: *passwordEnv})
+	if err != nil {
+		return err
+	}
+
+	if len(password) == 0 {
+		return errors.New("refusing to create a keystore with an empty password")
+	}
+
+	s, err := signer.GenerateKeystore(*out, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created keystore %s for address %s\n", *out, s.EthereumAddress().Hex())
+	return nil
+}
@@ -2,11 +2,19 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/gryd-database/platform-poc/cmd/server"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "keygen" {
+		if err := keygen(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := server.Init(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gryd-database/platform-poc/configuration"
+	"golang.org/x/sync/semaphore"
+)
+
+var (
+	errWalletBusy   = errors.New("too many on-chain operations queued for wallet")
+	errQueueTimeout = errors.New("timed out waiting for an on-chain operation slot")
+)
+
+const (
+	defaultAccessCapacity     = 1
+	defaultAccessWeight       = 1
+	defaultAccessQueueTimeout = 30 * time.Second
+	defaultAccessMaxPerWallet = 2
+)
+
+// AccessQueue reports the state of the on-chain operation guard
+type AccessQueue struct {
+	Queued   int64 `json:"queued"`
+	Active   int64 `json:"active"`
+	Capacity int64 `json:"capacity"`
+}
+
+// accessGuard bounds the concurrent on-chain operations. Requests wait in the FIFO queue of the
+// semaphore for at most timeout, and a single wallet cannot hold more than maxPerWallet queued or
+// running operations so it cannot starve the other wallets.
+type accessGuard struct {
+	semaphore    *semaphore.Weighted
+	capacity     int64
+	weight       int64
+	timeout      time.Duration
+	maxPerWallet int
+
+	lock    sync.Mutex
+	wallets map[string]int
+
+	queued atomic.Int64
+	active atomic.Int64
+}
+
+func newAccessGuard(config configuration.GRYDAccess) *accessGuard {
+	if config.Capacity <= 0 {
+		config.Capacity = defaultAccessCapacity
+	}
+	if config.Weight <= 0 {
+		config.Weight = defaultAccessWeight
+	}
+	// a weight above the capacity could never be acquired
+	if config.Weight > config.Capacity {
+		config.Weight = config.Capacity
+	}
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = defaultAccessQueueTimeout
+	}
+	if config.MaxPerWallet <= 0 {
+		config.MaxPerWallet = defaultAccessMaxPerWallet
+	}
+
+	return &accessGuard{
+		semaphore:    semaphore.NewWeighted(config.Capacity),
+		capacity:     config.Capacity,
+		weight:       config.Weight,
+		timeout:      config.QueueTimeout,
+		maxPerWallet: config.MaxPerWallet,
+		wallets:      make(map[string]int),
+	}
+}
+
+// acquire waits for an operation slot for the wallet, release must be called once the operation is done
+func (g *accessGuard) acquire(ctx context.Context, wallet string) error {
+	g.lock.Lock()
+	if g.wallets[wallet] >= g.maxPerWallet {
+		g.lock.Unlock()
+		return errWalletBusy
+	}
+	g.wallets[wallet]++
+	g.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	g.queued.Add(1)
+	err := g.semaphore.Acquire(ctx, g.weight)
+	g.queued.Add(-1)
+	if err != nil {
+		g.leave(wallet)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errQueueTimeout
+		}
+		return err
+	}
+
+	g.active.Add(1)
+	return nil
+}
+
+func (g *accessGuard) release(wallet string) {
+	g.active.Add(-1)
+	g.semaphore.Release(g.weight)
+	g.leave(wallet)
+}
+
+func (g *accessGuard) leave(wallet string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.wallets[wallet]--
+	if g.wallets[wallet] <= 0 {
+		delete(g.wallets, wallet)
+	}
+}
+
+func (g *accessGuard) depth() AccessQueue {
+	return AccessQueue{
+		Queued:   g.queued.Load(),
+		Active:   g.active.Load(),
+		Capacity: g.capacity / g.weight,
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gryd-database/platform-poc/configuration"
+)
+
+func TestAccessGuard(t *testing.T) {
+	t.Parallel()
+
+	t.Run("queue timeout", func(t *testing.T) {
+		t.Parallel()
+
+		guard := newAccessGuard(configuration.GRYDAccess{Capacity: 1, QueueTimeout: 10 * time.Millisecond, MaxPerWallet: 1})
+
+		if err := guard.acquire(context.Background(), "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		err := guard.acquire(context.Background(), "b")
+		if !errors.Is(err, errQueueTimeout) {
+			t.Fatalf("expected queue timeout, got %v", err)
+		}
+
+		guard.release("a")
+
+		if err := guard.acquire(context.Background(), "b"); err != nil {
+			t.Fatal(err)
+		}
+		guard.release("b")
+	})
+
+	t.Run("wallet busy", func(t *testing.T) {
+		t.Parallel()
+
+		guard := newAccessGuard(configuration.GRYDAccess{Capacity: 4, MaxPerWallet: 1})
+
+		if err := guard.acquire(context.Background(), "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		err := guard.acquire(context.Background(), "a")
+		if !errors.Is(err, errWalletBusy) {
+			t.Fatalf("expected wallet busy, got %v", err)
+		}
+
+		if err := guard.acquire(context.Background(), "b"); err != nil {
+			t.Fatal(err)
+		}
+
+		if depth := guard.depth(); depth.Active != 2 || depth.Capacity != 4 {
+			t.Fatalf("unexpected queue depth %+v", depth)
+		}
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		t.Parallel()
+
+		guard := newAccessGuard(configuration.GRYDAccess{Capacity: 2, Weight: 2, QueueTimeout: time.Second})
+
+		if err := guard.acquire(context.Background(), "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- guard.acquire(context.Background(), "b")
+		}()
+
+		deadline := time.Now().Add(time.Second)
+		for guard.depth().Queued != 1 {
+			if time.Now().After(deadline) {
+				t.Fatal("expected request to be queued")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		guard.release("a")
+
+		if err := <-acquired; err != nil {
+			t.Fatal(err)
+		}
+
+		if depth := guard.depth(); depth.Queued != 0 || depth.Active != 1 || depth.Capacity != 1 {
+			t.Fatalf("unexpected queue depth %+v", depth)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	// unauthenticated clients are keyed on their address, a new connection must not give them a new key
+	for remoteAddr, want := range map[string]string{
+		"192.0.2.1:51234":  "192.0.2.1",
+		"[2001:db8::1]:80": "2001:db8::1",
+		"192.0.2.1":        "192.0.2.1",
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/storage/create", nil)
+		r.RemoteAddr = remoteAddr
+
+		if got := clientIP(r); got != want {
+			t.Fatalf("expected %s for %s, got %s", want, remoteAddr, got)
+		}
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-chi/chi"
 	"github.com/gryd-database/platform-poc/configuration"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/auth/authMock"
 	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
 	"github.com/gryd-database/platform-poc/pkg/transaction/txMock"
 	"github.com/sirupsen/logrus"
+	"net/http"
 	"testing"
+	"time"
 )
 
 type testServerOptions struct {
@@ -20,12 +25,13 @@ type testServerOptions struct {
 	dbServiceOpts           storage.DBService
 	odbServiceOpts          storage.OrbitService
 	grydContractServiceOpts storage.GRYDContract
+	authServiceOpts         auth.Service
 }
 
 func newTestServer(t *testing.T, o testServerOptions) *Container {
 	t.Helper()
 
-	transaction := txMock.New(o.txServiceOpts...)
+	txService := txMock.New(o.txServiceOpts...)
 
 	dbService := o.dbServiceOpts
 
@@ -38,12 +44,34 @@ func newTestServer(t *testing.T, o testServerOptions) *Container {
 		t.Fatal(err)
 	}
 
-	storageController := New(logrus.New(), storageService, dbService, contractService)
+	authService := o.authServiceOpts
+	if authService == nil {
+		// by default the bearer token of a test request is the address of the wallet it is sent by, granted every scope
+		authService = authMock.New(authMock.WithAuthenticate(func(token string) (*auth.Claims, error) {
+			if !common.IsHexAddress(token) {
+				return nil, auth.ErrInvalidToken
+			}
+			return &auth.Claims{Subject: token, Type: auth.TokenTypeAccess, Scopes: auth.DefaultScopes}, nil
+		}))
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = logrus.New()
+	}
 
-	s := ContainerBootstrapper(nil, o.ethAddress, &transaction, &BootedServices{config: config}, storageController)
+	storageController := New(logger, storageService, dbService, contractService, storage.QuotaLimits{})
+
+	s := ContainerBootstrapper(nil, o.ethAddress, &txService, &BootedServices{config: config, logger: logger}, storageController, authService, transaction.NewSyncChecker(txService, time.Minute))
 
 	s.cors()
 	s.routes()
 
 	return s
 }
+
+// authenticate sets the bearer token the default test auth service accepts for the wallet
+func authenticate(req *http.Request, wallet string) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+wallet)
+	return req
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func NewAuthController(logger *logrus.Logger, authService auth.Service) *AuthController {
+	return &AuthController{
+		logger:      logger,
+		authService: authService,
+	}
+}
+
+type AuthController struct {
+	logger      *logrus.Logger
+	authService auth.Service
+}
+
+// LoginRequest holds the signed sign-in message, Signature is the hex encoded personal_sign signature
+type LoginRequest struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// RefreshRequest holds the refresh token issued on login or on a previous refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (c *AuthController) Nonce(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if !walletRegex.MatchString(address) {
+		logger.FromContext(r.Context(), c.logger).Info("invalid wallet address:" + address)
+		WriteJson(w, "invalid wallet address", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := c.authService.Nonce(common.HexToAddress(address))
+	if errors.Is(err, auth.ErrTooManyNonces) {
+		logger.FromContext(r.Context(), c.logger).Info("unable to issue nonce: ", err)
+		WriteJson(w, "too many pending sign-ins", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, challenge, http.StatusOK)
+}
+
+func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
+	var request LoginRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("unable to parse login request: ", err)
+		WriteJson(w, "unable to parse login request", http.StatusBadRequest)
+		return
+	}
+
+	signature, err := hexutil.Decode(request.Signature)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid signature encoding: ", err)
+		WriteJson(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	session, err := c.authService.Login(request.Message, signature)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidMessage) {
+			logger.FromContext(r.Context(), c.logger).Info("invalid sign-in message: ", err)
+			WriteJson(w, "invalid sign-in message", http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, auth.ErrInvalidSignature) || errors.Is(err, auth.ErrUnknownNonce) || errors.Is(err, auth.ErrMessageExpired) {
+			logger.FromContext(r.Context(), c.logger).Info("sign-in rejected: ", err)
+			WriteJson(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if errors.Is(err, auth.ErrTooManySessions) {
+			logger.FromContext(r.Context(), c.logger).Info("unable to issue session: ", err)
+			WriteJson(w, "too many active sessions", http.StatusServiceUnavailable)
+			return
+		}
+
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, session, http.StatusOK)
+}
+
+func (c *AuthController) Refresh(w http.ResponseWriter, r *http.Request) {
+	var request RefreshRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("unable to parse refresh request: ", err)
+		WriteJson(w, "unable to parse refresh request", http.StatusBadRequest)
+		return
+	}
+
+	session, err := c.authService.Refresh(request.RefreshToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			logger.FromContext(r.Context(), c.logger).Info("refresh rejected: ", err)
+			WriteJson(w, "invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, session, http.StatusOK)
+}
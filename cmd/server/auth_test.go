@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/auth/authMock"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestNonce(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+	nonce := "/auth/nonce?address=" + address
+
+	t.Run("too many nonces", func(t *testing.T) {
+		t.Parallel()
+
+		authService := authMock.New(authMock.WithNonce(func(address common.Address) (*auth.Challenge, error) {
+			return nil, auth.ErrTooManyNonces
+		}))
+
+		testServer := newTestServer(t, testServerOptions{authServiceOpts: authService})
+
+		req := httptest.NewRequest(http.MethodGet, nonce, nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusServiceUnavailable)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		t.Parallel()
+
+		authService := authMock.New(authMock.WithNonce(func(address common.Address) (*auth.Challenge, error) {
+			return &auth.Challenge{Nonce: "nonce"}, nil
+		}))
+
+		testServer := newTestServer(t, testServerOptions{authServiceOpts: authService})
+		// a fixed clock keeps the bucket from refilling while the test runs
+		now := time.Now()
+		testServer.rateLimiter.now = func() time.Time { return now }
+
+		for i := 0; i < defaultRateLimitBurst; i++ {
+			rr := httptest.NewRecorder()
+			testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, nonce, nil))
+
+			assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+		}
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, nonce, nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusTooManyRequests)
+	})
+}
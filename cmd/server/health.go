@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gryd-database/platform-poc/pkg/logger"
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+	statusAlive    = "alive"
+
+	checkOK     = "ok"
+	checkFailed = "failed"
+
+	healthCheckTimeout = 5 * time.Second
+	// the public probes are answered from a cache so frequent hits do not call the chain backend every time
+	healthCacheTTL = 5 * time.Second
+)
+
+var (
+	errStoreNotLoaded = errors.New("store not loaded")
+	errIPFSOffline    = errors.New("ipfs node offline")
+)
+
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+type checkResult struct {
+	Status string      `json:"status"`
+	Detail interface{} `json:"detail,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type syncDetail struct {
+	Synced    bool       `json:"synced"`
+	BlockTime *time.Time `json:"blockTime,omitempty"`
+}
+
+type statusResponse struct {
+	Address       string `json:"address"`
+	EthBalance    string `json:"ethBalance"`
+	GrydBalance   string `json:"grydBalance"`
+	OrbitDBID     string `json:"orbitDbId,omitempty"`
+	StoreAddress  string `json:"storeAddress,omitempty"`
+	LedgerAddress string `json:"ledgerAddress,omitempty"`
+}
+
+// readinessCheck returns details about a dependency of the node, or an error when it cannot serve requests
+type readinessCheck func(ctx context.Context) (interface{}, error)
+
+// responseCache keeps the last response of a handler for healthCacheTTL, concurrent requests wait for the one
+// computing a new response instead of computing their own. The response is shared by every caller so it must not
+// depend on the request that computed it.
+type responseCache struct {
+	lock    sync.Mutex
+	checked time.Time
+	body    interface{}
+	code    int
+}
+
+// serve writes the cached response while it is fresh, otherwise the response of compute is cached and written.
+// Internal errors are not cached so the next request computes the response again.
+func (rc *responseCache) serve(w http.ResponseWriter, compute func() (interface{}, int)) {
+	rc.lock.Lock()
+	body, code := rc.body, rc.code
+	if time.Since(rc.checked) >= healthCacheTTL {
+		body, code = compute()
+		if code != http.StatusInternalServerError {
+			rc.body, rc.code, rc.checked = body, code, time.Now()
+		}
+	}
+	rc.lock.Unlock()
+
+	WriteJson(w, body, code)
+}
+
+// liveness reports that the process is up, it does not look at any dependency
+func (c *Container) liveness(w http.ResponseWriter, r *http.Request) {
+	WriteJson(w, map[string]string{"status": statusAlive}, http.StatusOK)
+}
+
+// readiness reports whether the node can serve storage requests, it is not ready as soon as one of its checks fails
+func (c *Container) readiness(w http.ResponseWriter, r *http.Request) {
+	c.readinessCache.serve(w, func() (interface{}, int) {
+		return c.checkReadiness(r)
+	})
+}
+
+// checkReadiness runs the readiness checks and returns the response along with its status code, the checks do not
+// use the context of the request as their result is cached for the other callers
+func (c *Container) checkReadiness(r *http.Request) (interface{}, int) {
+	response := readinessResponse{Status: statusReady, Checks: make(map[string]checkResult)}
+
+	for name, check := range c.readinessChecks() {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		detail, err := check(ctx)
+		cancel()
+
+		if err != nil {
+			logger.FromContext(r.Context(), c.logger).Warn("readiness check ", name, " failed: ", err)
+
+			response.Status = statusNotReady
+			response.Checks[name] = checkResult{Status: checkFailed, Detail: detail, Error: err.Error()}
+			continue
+		}
+
+		response.Checks[name] = checkResult{Status: checkOK, Detail: detail}
+	}
+
+	if response.Status != statusReady {
+		return response, http.StatusServiceUnavailable
+	}
+
+	return response, http.StatusOK
+}
+
+// readinessChecks returns the checks of the services the node was started with
+func (c *Container) readinessChecks() map[string]readinessCheck {
+	checks := map[string]readinessCheck{
+		"chain": c.checkChain,
+		"sync":  c.checkSync,
+	}
+
+	if c.pg != nil {
+		checks["postgres"] = func(ctx context.Context) (interface{}, error) {
+			return nil, c.pg.Ping(ctx)
+		}
+	}
+
+	if c.odb != nil {
+		checks["orbitdb"] = func(ctx context.Context) (interface{}, error) {
+			if !c.odb.Loaded() {
+				return nil, errStoreNotLoaded
+			}
+			return nil, nil
+		}
+		checks["ipfs"] = func(ctx context.Context) (interface{}, error) {
+			peers := map[string]int{"peers": c.odb.PeerCount()}
+			if !c.odb.Online() {
+				return peers, errIPFSOffline
+			}
+			return peers, nil
+		}
+	}
+
+	return checks
+}
+
+func (c *Container) checkChain(ctx context.Context) (interface{}, error) {
+	block, err := (*c.txService).BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]uint64{"block": block}, nil
+}
+
+func (c *Container) checkSync(ctx context.Context) (interface{}, error) {
+	synced, blockTime, err := c.syncChecker.Synced(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := syncDetail{Synced: synced, BlockTime: &blockTime}
+	if !synced {
+		return detail, storage.ErrChainNotSynced
+	}
+
+	return detail, nil
+}
+
+// status returns the identity of the node on chain and in orbitdb along with its balances. It is public on purpose:
+// the address and the balances can be read from the chain by anyone and the orbitdb ids are announced to the peers.
+func (c *Container) status(w http.ResponseWriter, r *http.Request) {
+	c.statusCache.serve(w, func() (interface{}, int) {
+		return c.nodeStatus(r)
+	})
+}
+
+// nodeStatus looks up the balances of the node and returns the response along with its status code, the lookups do
+// not use the context of the request as their result is cached for the other callers
+func (c *Container) nodeStatus(r *http.Request) (interface{}, int) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	ethBalance, err := (*c.txService).Balance(ctx)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to get eth balance: ", err)
+		return "internal server error", http.StatusInternalServerError
+	}
+
+	grydBalance, err := c.storageController.grydService.GetBalance(ctx)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to get gryd balance: ", err)
+		return "internal server error", http.StatusInternalServerError
+	}
+
+	response := statusResponse{
+		Address:     c.ethAddress.Hex(),
+		EthBalance:  ethBalance.String(),
+		GrydBalance: grydBalance.String(),
+	}
+
+	if c.odb != nil {
+		response.OrbitDBID = c.odb.GetOwnID()
+		response.StoreAddress, response.LedgerAddress = c.odb.StoreAddresses()
+	}
+
+	return response, http.StatusOK
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gryd-database/platform-poc/pkg/storage/grydContractMock"
+	"github.com/gryd-database/platform-poc/pkg/transaction/txMock"
+	"github.com/magiconair/properties/assert"
+	"github.com/pkg/errors"
+)
+
+// withBlockTime makes the chain backend of the test server report its last block at blockTime
+func withBlockTime(blockTime time.Time) []txMock.Option {
+	return []txMock.Option{
+		txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+			return 100, nil
+		}),
+		txMock.WithHeaderByNumberFunc(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: number, Time: uint64(blockTime.Unix())}, nil
+		}),
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	t.Parallel()
+
+	testServer := newTestServer(t, testServerOptions{})
+
+	rr := httptest.NewRecorder()
+	testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	t.Run("synced", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{txServiceOpts: withBlockTime(time.Now())})
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+		var response readinessResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, response.Checks["chain"].Status, checkOK)
+		assert.Equal(t, response.Checks["sync"].Status, checkOK)
+	})
+
+	t.Run("lagging", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{txServiceOpts: withBlockTime(time.Now().Add(-time.Hour))})
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusServiceUnavailable)
+
+		var response readinessResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, response.Checks["chain"].Status, checkOK)
+		assert.Equal(t, response.Checks["sync"].Status, checkFailed)
+	})
+
+	t.Run("backend unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{})
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusServiceUnavailable)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	address := common.HexToAddress("0x30ceb6AF5A7b8E2C2EE4D50cf36C2e4A7c0aa4E1")
+
+	t.Run("balances", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			ethAddress: address,
+			txServiceOpts: []txMock.Option{txMock.WithBalanceFunc(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(1000), nil
+			})},
+			grydContractServiceOpts: grydContractMock.New(grydContractMock.WithGetBalance(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(42), nil
+			})),
+		})
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+		var response statusResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, response, statusResponse{Address: address.Hex(), EthBalance: "1000", GrydBalance: "42"})
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+		testServer := newTestServer(t, testServerOptions{
+			ethAddress: address,
+			txServiceOpts: []txMock.Option{txMock.WithBalanceFunc(func(ctx context.Context) (*big.Int, error) {
+				atomic.AddInt32(&calls, 1)
+				return big.NewInt(1000), nil
+			})},
+			grydContractServiceOpts: grydContractMock.New(grydContractMock.WithGetBalance(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(42), nil
+			})),
+		})
+
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+		}
+
+		// the balances are only looked up once within healthCacheTTL
+		assert.Equal(t, atomic.LoadInt32(&calls), int32(1))
+	})
+
+	t.Run("backend unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			ethAddress: address,
+			txServiceOpts: []txMock.Option{txMock.WithBalanceFunc(func(ctx context.Context) (*big.Int, error) {
+				return nil, errors.New("connection refused")
+			})},
+		})
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusInternalServerError)
+	})
+
+	t.Run("error not cached", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+		testServer := newTestServer(t, testServerOptions{
+			ethAddress: address,
+			txServiceOpts: []txMock.Option{txMock.WithBalanceFunc(func(ctx context.Context) (*big.Int, error) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return nil, errors.New("connection refused")
+				}
+				return big.NewInt(1000), nil
+			})},
+			grydContractServiceOpts: grydContractMock.New(grydContractMock.WithGetBalance(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(42), nil
+			})),
+		})
+
+		for _, want := range []int{http.StatusInternalServerError, http.StatusOK} {
+			rr := httptest.NewRecorder()
+			testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			assert.Equal(t, rr.Result().StatusCode, want)
+		}
+	})
+
+	t.Run("cancelled request", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			ethAddress: address,
+			txServiceOpts: []txMock.Option{txMock.WithBalanceFunc(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(1000), ctx.Err()
+			})},
+			grydContractServiceOpts: grydContractMock.New(grydContractMock.WithGetBalance(func(ctx context.Context) (*big.Int, error) {
+				return big.NewInt(42), ctx.Err()
+			})),
+		})
+
+		// the balances are cached for every caller, the request that looks them up must not cancel the lookup
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/status", nil).WithContext(ctx))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"github.com/gryd-database/platform-poc/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds the ids accepted from clients so they cannot flood the logs
+	maxRequestIDLength = 128
+)
+
+// requestIDHandler keeps the request id sent by the client or generates one, the id is sent back and every log of
+// the request carries it
+func (c *Container) requestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := logger.NewContext(r.Context(), c.logger.WithField("requestId", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// accessLogHandler logs every request once it was served with the fields the handlers added to its entry
+func (c *Container) accessLogHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		entry := logger.FromContext(r.Context(), c.logger).WithFields(logrus.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"route":      chi.RouteContext(r.Context()).RoutePattern(),
+			"status":     status,
+			"bytes":      ww.BytesWritten(),
+			"durationMs": time.Since(start).Milliseconds(),
+			"remoteAddr": r.RemoteAddr,
+			"userAgent":  r.UserAgent(),
+		})
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("request served")
+		case status >= http.StatusBadRequest:
+			entry.Warn("request served")
+		default:
+			entry.Info("request served")
+		}
+	})
+}
+
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
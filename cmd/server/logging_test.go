@@ -0,0 +1,90 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	testServer := newTestServer(t, testServerOptions{})
+
+	for _, tc := range []struct {
+		name      string
+		requestID string
+		kept      bool
+	}{
+		{name: "kept", requestID: "6a7c1e42-client", kept: true},
+		{name: "generated", requestID: ""},
+		{name: "invalid", requestID: "id with spaces"},
+		{name: "too long", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			req.Header.Set(requestIDHeader, tc.requestID)
+
+			rr := httptest.NewRecorder()
+			testServer.router.ServeHTTP(rr, req)
+
+			requestID := rr.Result().Header.Get(requestIDHeader)
+			if tc.kept {
+				assert.Equal(t, requestID, tc.requestID)
+				return
+			}
+			if !validRequestID(requestID) || requestID == tc.requestID {
+				t.Fatalf("expected a generated request id, got %q", requestID)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+
+	log, hook := test.NewNullLogger()
+	testServer := newTestServer(t, testServerOptions{logger: log})
+
+	req, err := Upload(map[string]io.Reader{
+		"file":   mustOpen("../../sampleData.csv"),
+		"txHash": strings.NewReader("0x01"),
+	}, "/storage/create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(requestIDHeader, "access-log-test")
+
+	rr := httptest.NewRecorder()
+	testServer.router.ServeHTTP(rr, authenticate(req, address))
+	assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+
+	entries := hook.AllEntries()
+	if len(entries) < 2 {
+		t.Fatalf("expected the handler and access logs, got %d entries", len(entries))
+	}
+
+	// the handler logs with the fields known so far, the access log with every field the request added
+	for _, entry := range entries {
+		assert.Equal(t, entry.Data["requestId"], "access-log-test")
+		assert.Equal(t, entry.Data["wallet"], address)
+		assert.Equal(t, entry.Data["txHash"], "0x01")
+	}
+
+	accessLog := hook.LastEntry()
+	assert.Equal(t, accessLog.Message, "request served")
+	assert.Equal(t, accessLog.Level, logrus.WarnLevel)
+	assert.Equal(t, accessLog.Data["route"], "/storage/create")
+	assert.Equal(t, accessLog.Data["status"], http.StatusBadRequest)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gryd",
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of http requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gryd",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the http requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	eventVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gryd",
+		Subsystem: "storage",
+		Name:      "event_verifications_total",
+		Help:      "Number of tx event verifications by outcome.",
+	}, []string{"outcome"})
+)
+
+// verificationOutcomes labels the errors of an event verification, any other error is counted as "error"
+var verificationOutcomes = []struct {
+	err     error
+	outcome string
+}{
+	{err: transaction.ErrEventNotFound, outcome: "event_not_found"},
+	{err: transaction.ErrTransactionReverted, outcome: "tx_reverted"},
+	{err: transaction.ErrNoTopic, outcome: "no_topic"},
+	{err: storage.ErrEventNotIndexed, outcome: "event_not_indexed"},
+	{err: storage.ErrEventNotConfirmed, outcome: "event_not_confirmed"},
+	{err: storage.ErrBlockNotCanonical, outcome: "block_not_canonical"},
+	{err: storage.ErrChainNotSynced, outcome: "chain_not_synced"},
+	{err: storage.ErrUnprocessableEvent, outcome: "unprocessable_event"},
+}
+
+// metricMethods are the request methods used as label, any other method is counted as "other" so arbitrary
+// methods sent by clients do not create new series
+var metricMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// metricsHandler counts the requests and records their latency by route pattern, so the path parameters of a
+// route do not create new series
+func (c *Container) metricsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		method := methodLabel(r.Method)
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	})
+}
+
+func methodLabel(method string) string {
+	if metricMethods[method] {
+		return method
+	}
+
+	return "other"
+}
+
+// verifyEvent verifies the events of the tx and counts the outcome
+func (c *StorageController) verifyEvent(ctx context.Context, txHash string) ([]storage.EventInsertDataSuccess, error) {
+	events, err := c.grydService.VerifyEvent(ctx, txHash)
+	eventVerifications.WithLabelValues(verificationOutcome(err)).Inc()
+
+	return events, err
+}
+
+func verificationOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	for _, o := range verificationOutcomes {
+		if errors.Is(err, o.err) {
+			return o.outcome
+		}
+	}
+
+	return "error"
+}
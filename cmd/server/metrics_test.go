@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	testServer := newTestServer(t, testServerOptions{})
+
+	rr := httptest.NewRecorder()
+	testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+	rr = httptest.NewRecorder()
+	testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/storage/get/1", nil))
+	assert.Equal(t, rr.Result().StatusCode, http.StatusUnauthorized)
+
+	rr = httptest.NewRecorder()
+	testServer.adminRouter.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+	body, err := io.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, series := range []string{
+		`gryd_http_requests_total{code="200",method="GET",route="/healthz"}`,
+		// requests rejected before reaching a sub-router are labelled with its mount pattern
+		`gryd_http_requests_total{code="401",method="GET",route="/storage/*"}`,
+		`gryd_http_request_duration_seconds_count{method="GET",route="/healthz"}`,
+	} {
+		if !strings.Contains(string(body), series) {
+			t.Fatalf("missing series %s", series)
+		}
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	t.Parallel()
+
+	testServer := newTestServer(t, testServerOptions{})
+
+	// the operator endpoints are only served on the admin address
+	for _, path := range []string{"/queue", "/metrics"} {
+		rr := httptest.NewRecorder()
+		testServer.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
+
+		rr = httptest.NewRecorder()
+		testServer.adminRouter.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		method string
+		label  string
+	}{
+		{method: http.MethodGet, label: http.MethodGet},
+		{method: http.MethodDelete, label: http.MethodDelete},
+		{method: "get", label: "other"},
+		{method: "PROPFIND", label: "other"},
+	} {
+		assert.Equal(t, methodLabel(tc.method), tc.label)
+	}
+}
+
+func TestVerificationOutcome(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		err     error
+		outcome string
+	}{
+		{err: nil, outcome: "ok"},
+		{err: fmt.Errorf("unable to get receipt: %w", transaction.ErrEventNotFound), outcome: "event_not_found"},
+		{err: storage.ErrUnprocessableEvent, outcome: "unprocessable_event"},
+		{err: storage.ErrChainNotSynced, outcome: "chain_not_synced"},
+		{err: fmt.Errorf("connection refused"), outcome: "error"},
+	} {
+		assert.Equal(t, verificationOutcome(tc.err), tc.outcome)
+	}
+}
@@ -1,15 +1,112 @@
 package server
 
-import "net/http"
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 
-func (c *Container) grydAccessHandler() http.Handler {
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// authenticationHandler rejects requests without a valid bearer access token and stores its
+// claims in the request context
+func (c *Container) authenticationHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !c.grydSemaphore.TryAcquire(1) {
-			c.logger.Debug("gryd access: simultaneous on-chain operations not supported")
-			c.logger.Error(nil, "staking access: simultaneous on-chain operations not supported")
-			WriteJson(w, "simultaneous on-chain operations not supported", http.StatusTooManyRequests)
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || len(token) == 0 {
+			logger.FromContext(r.Context(), c.logger).Info("missing bearer token")
+			WriteJson(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := c.authService.Authenticate(token)
+		if err != nil {
+			logger.FromContext(r.Context(), c.logger).Info("unable to authenticate request: ", err)
+			WriteJson(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
-		defer c.grydSemaphore.Release(1)
+
+		logger.AddFields(r.Context(), logrus.Fields{"wallet": claims.Subject})
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
 	})
 }
+
+// scopeHandler rejects authenticated requests whose token was not granted the scope
+func (c *Container) scopeHandler(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok || !claims.HasScope(scope) {
+				logger.FromContext(r.Context(), c.logger).Info("token is missing scope: " + scope)
+				WriteJson(w, "token is missing scope "+scope, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// grydAccessHandler queues requests doing on-chain operations behind the access guard, requests are
+// keyed on the authenticated wallet or the client address when unauthenticated
+func (c *Container) grydAccessHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wallet := clientIP(r)
+		if address, ok := auth.AddressFromContext(r.Context()); ok {
+			wallet = address.Hex()
+		}
+
+		err := c.grydAccess.acquire(r.Context(), wallet)
+		if err != nil {
+			if errors.Is(err, errWalletBusy) || errors.Is(err, errQueueTimeout) {
+				logger.FromContext(r.Context(), c.logger).Info("gryd access: ", err, " wallet: ", wallet)
+				w.Header().Set("Retry-After", strconv.Itoa(int(c.grydAccess.timeout.Seconds())))
+				WriteJson(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+
+			// the client went away while queued
+			logger.FromContext(r.Context(), c.logger).Debug("gryd access: ", err)
+			return
+		}
+		defer c.grydAccess.release(wallet)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitHandler limits the requests of the authenticated wallet and of the client address
+func (c *Container) rateLimitHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []string{"ip:" + clientIP(r)}
+		if address, ok := auth.AddressFromContext(r.Context()); ok {
+			keys = append(keys, "wallet:"+address.Hex())
+		}
+
+		allowed, wait := c.rateLimiter.allow(keys...)
+		if !allowed {
+			logger.FromContext(r.Context(), c.logger).Info("rate limit exceeded for: ", keys)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			WriteJson(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the address of the client without its port, so every connection of a client shares its limits
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gryd-database/platform-poc/configuration"
+)
+
+const (
+	defaultRateLimitRate  = 5
+	defaultRateLimitBurst = 10
+	// buckets idle for longer than bucketIdleTimeout are refilled anyway and can be dropped
+	bucketIdleTimeout = 10 * time.Minute
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed on arbitrary strings, every key gets a bucket of
+// burst tokens refilled at rate tokens per second
+type rateLimiter struct {
+	lock      sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastPurge time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter(config configuration.RateLimit) *rateLimiter {
+	if config.Rate <= 0 {
+		config.Rate = defaultRateLimitRate
+	}
+	if config.Burst <= 0 {
+		config.Burst = defaultRateLimitBurst
+	}
+
+	return &rateLimiter{
+		rate:    config.Rate,
+		burst:   float64(config.Burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from the bucket of every key, nothing is taken unless all of them have a token.
+// When a bucket is empty it returns how long to wait for the next token.
+func (l *rateLimiter) allow(keys ...string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.purge(now)
+
+	var wait time.Duration
+	for _, key := range keys {
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{tokens: l.burst}
+			l.buckets[key] = b
+		} else {
+			b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		}
+		b.last = now
+
+		if b.tokens < 1 {
+			missing := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+			if missing > wait {
+				wait = missing
+			}
+		}
+	}
+
+	if wait > 0 {
+		return false, wait
+	}
+
+	for _, key := range keys {
+		l.buckets[key].tokens--
+	}
+
+	return true, 0
+}
+
+// purge drops the buckets of idle keys, the lock must be held by the caller
+func (l *rateLimiter) purge(now time.Time) {
+	if now.Sub(l.lastPurge) < bucketIdleTimeout {
+		return
+	}
+	l.lastPurge = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
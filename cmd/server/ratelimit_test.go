@@ -0,0 +1,73 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gryd-database/platform-poc/configuration"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("burst and refill", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(0, 0)
+		limiter := newRateLimiter(configuration.RateLimit{Rate: 2, Burst: 2})
+		limiter.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			if ok, _ := limiter.allow("wallet:a"); !ok {
+				t.Fatalf("request %d should be allowed", i)
+			}
+		}
+
+		ok, wait := limiter.allow("wallet:a")
+		if ok || wait != 500*time.Millisecond {
+			t.Fatalf("expected rejection with 500ms wait, got %v %v", ok, wait)
+		}
+
+		now = now.Add(500 * time.Millisecond)
+		if ok, _ := limiter.allow("wallet:a"); !ok {
+			t.Fatal("request should be allowed after refill")
+		}
+	})
+
+	t.Run("all keys", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(0, 0)
+		limiter := newRateLimiter(configuration.RateLimit{Rate: 1, Burst: 1})
+		limiter.now = func() time.Time { return now }
+
+		if ok, _ := limiter.allow("ip:1", "wallet:a"); !ok {
+			t.Fatal("first request should be allowed")
+		}
+
+		if ok, _ := limiter.allow("ip:2", "wallet:a"); ok {
+			t.Fatal("wallet bucket should be empty")
+		}
+
+		// the rejected request must not have taken the token of ip:2
+		if ok, _ := limiter.allow("ip:2", "wallet:b"); !ok {
+			t.Fatal("request of another wallet should be allowed")
+		}
+	})
+
+	t.Run("purge idle buckets", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(0, 0).Add(bucketIdleTimeout)
+		limiter := newRateLimiter(configuration.RateLimit{})
+		limiter.now = func() time.Time { return now }
+
+		limiter.allow("wallet:a")
+		now = now.Add(2 * bucketIdleTimeout)
+		limiter.allow("wallet:b")
+
+		if _, ok := limiter.buckets["wallet:a"]; ok {
+			t.Fatal("idle bucket should be purged")
+		}
+	})
+}
@@ -10,18 +10,25 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	"github.com/gryd-database/platform-poc/configuration"
+	"github.com/gryd-database/platform-poc/pkg/auth"
 	"github.com/gryd-database/platform-poc/pkg/node"
 	"github.com/gryd-database/platform-poc/pkg/odb"
 	"github.com/gryd-database/platform-poc/pkg/pg"
 	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/tracing"
 	"github.com/gryd-database/platform-poc/pkg/transaction"
-	"golang.org/x/sync/semaphore"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/gryd-database/platform-poc/pkg/logger"
 )
@@ -30,20 +37,37 @@ type Container struct {
 	config            *configuration.Config
 	logger            *logrus.Logger
 	router            *chi.Mux
+	adminRouter       *chi.Mux
 	pg                *pgxpool.Pool
 	storageController *StorageController
+	authController    *AuthController
+	authService       auth.Service
 	ethAddress        common.Address
 	txService         *transaction.Service
 	odb               *odb.Database
 	rpcClient         *rpc.Client
-	grydSemaphore     *semaphore.Weighted
+	grydAccess        *accessGuard
+	rateLimiter       *rateLimiter
+	syncChecker       *transaction.SyncChecker
+	readinessCache    responseCache
+	statusCache       responseCache
+	tracerProvider    *sdktrace.TracerProvider
+	server            *http.Server
+	adminServer       *http.Server
+	workers           []io.Closer
+
+	// uploadsLock orders the start of an upload with draining so waitUploads does not miss any upload
+	uploadsLock sync.Mutex
+	uploads     sync.WaitGroup
+	draining    bool
 }
 
 type BootedServices struct {
-	config *configuration.Config
-	logger *logrus.Logger
-	pg     *pgxpool.Pool
-	odb    *odb.Database
+	config         *configuration.Config
+	logger         *logrus.Logger
+	pg             *pgxpool.Pool
+	odb            *odb.Database
+	tracerProvider *sdktrace.TracerProvider
 }
 
 func Init() error {
@@ -54,31 +78,111 @@ func Init() error {
 
 	services.logger.Info("Container Initialized Successfully")
 
+	prometheus.MustRegister(pg.NewPoolCollector(services.pg))
+
 	GRYDContractAddress, GRYDContractABI, err := setContracts(services.config.GRYDContract.Address, services.config.GRYDContract.ABI)
 	if err != nil {
 		services.logger.Error("failed to parse contract abi, ", err)
 		return fmt.Errorf("err loading gryd contract: %w", err)
 	}
 
-	rpcClient, ethAddress, txService, err := node.InitChain(context.Background(), services.logger, services.config.ChainConfig.Endpoint, services.config.ChainConfig.PrivateKey)
+	nodeSigner, err := node.LoadSigner(services.logger, services.config.ChainConfig)
+	if err != nil {
+		services.logger.Error("failed to load node key: ", err)
+		return fmt.Errorf("err loading node key: %w", err)
+	}
+
+	chainOptions := node.ChainOptions{
+		Monitor: transaction.MonitorOptions{
+			PollInterval: services.config.TxMonitor.PollInterval,
+			StuckAfter:   services.config.TxMonitor.StuckAfter,
+			ResendBoost:  services.config.TxMonitor.ResendBoost,
+		},
+		Backend: transaction.MultiBackendOptions{
+			HealthCheckInterval: services.config.Backend.HealthCheckInterval,
+			MaxSyncDelay:        services.config.Sync.MaxDelay,
+			Quorum:              services.config.Backend.Quorum,
+		},
+		Retry: transaction.RetryOptions{
+			Timeout:        services.config.Backend.Timeout,
+			MaxRetries:     services.config.Backend.MaxRetries,
+			InitialBackoff: services.config.Backend.InitialBackoff,
+			MaxBackoff:     services.config.Backend.MaxBackoff,
+			ReceiptGrace:   services.config.Backend.ReceiptGrace,
+		},
+		WaitSynced:   services.config.Sync.WaitAtStartup,
+		SyncMaxDelay: services.config.Sync.MaxDelay,
+	}
+
+	rpcClient, ethAddress, txService, err := node.InitChain(context.Background(), services.logger, node.Endpoints(services.config.ChainConfig), nodeSigner, transaction.NewPostgresStore(services.pg), chainOptions)
+	if err != nil {
+		services.logger.Error("failed to initialize chain: ", err)
+		return fmt.Errorf("err initializing chain: %w", err)
+	}
 
-	grydContract := storage.NewContract(txService, ethAddress, services.logger, GRYDContractAddress, GRYDContractABI)
+	grydContract := storage.NewContract(txService, ethAddress, services.logger, GRYDContractAddress, GRYDContractABI, services.config.Reorg.Confirmations)
 
 	odbStorage, dbStorage := storage.New(
 		ethAddress,
 		services.logger,
 		services.pg, services.odb.Store, services.odb.Ledger)
 
-	storageController := New(services.logger, odbStorage, dbStorage, grydContract)
+	var workers []io.Closer
+
+	if services.config.Indexer.Enabled {
+		indexer := storage.NewIndexer(txService, dbStorage, services.logger, GRYDContractAddress, GRYDContractABI, storage.IndexerOptions{
+			StartBlock:    services.config.Indexer.StartBlock,
+			Confirmations: services.config.Indexer.Confirmations,
+			PollInterval:  services.config.Indexer.PollInterval,
+			BatchSize:     services.config.Indexer.BatchSize,
+		})
+		indexer.Start()
+		workers = append(workers, indexer)
+
+		grydContract = storage.NewIndexedContract(grydContract, dbStorage)
+	}
+
+	syncChecker := transaction.NewSyncChecker(*txService, services.config.Sync.MaxDelay)
+	grydContract = storage.NewSyncedContract(grydContract, syncChecker)
+
+	reorgWatcher := storage.NewReorgWatcher(txService, odbStorage, dbStorage, services.logger, storage.ReorgOptions{
+		Confirmations: services.config.Reorg.Confirmations,
+		Interval:      services.config.Reorg.RecheckInterval,
+		Grace:         services.config.Reorg.Grace,
+		Rollback:      services.config.Reorg.Rollback,
+	})
+	reorgWatcher.Start()
+	workers = append(workers, reorgWatcher)
+
+	storageController := New(services.logger, odbStorage, dbStorage, grydContract, storage.QuotaLimits{
+		MaxRows:  services.config.Quota.MaxRows,
+		MaxBytes: services.config.Quota.MaxBytes,
+	})
+
+	authService, err := auth.New(auth.Options{
+		Domain:      services.config.Auth.Domain,
+		URI:         services.config.Auth.URI,
+		Statement:   services.config.Auth.Statement,
+		ChainID:     services.config.Auth.ChainID,
+		Secret:      []byte(services.config.JWTSecret),
+		Scopes:      services.config.Auth.Scopes,
+		NonceTTL:    services.config.Auth.NonceTTL,
+		SessionTTL:  services.config.Auth.SessionTTL,
+		RefreshTTL:  services.config.Auth.RefreshTTL,
+		MaxNonces:   services.config.Auth.MaxNonces,
+		MaxSessions: services.config.Auth.MaxSessions,
+	})
+	if err != nil {
+		services.logger.Error("failed to initialize auth service: ", err)
+		return fmt.Errorf("err loading auth service: %w", err)
+	}
 
-	container := ContainerBootstrapper(rpcClient, ethAddress, txService, services, storageController)
+	container := ContainerBootstrapper(rpcClient, ethAddress, txService, services, storageController, authService, syncChecker)
+	container.workers = workers
 	container.cors()
 	container.routes()
 
-	go func() {
-		container.startServer()
-	}()
-	select {}
+	return container.run()
 }
 
 func ContainerBootstrapper(
@@ -86,19 +190,27 @@ func ContainerBootstrapper(
 	address common.Address,
 	txService *transaction.Service,
 	services *BootedServices,
-	storageController *StorageController) *Container {
+	storageController *StorageController,
+	authService auth.Service,
+	syncChecker *transaction.SyncChecker) *Container {
 
 	return &Container{
 		config:            services.config,
 		logger:            services.logger,
 		router:            chi.NewRouter(),
+		adminRouter:       chi.NewRouter(),
 		pg:                services.pg,
 		storageController: storageController,
+		authController:    NewAuthController(services.logger, authService),
+		authService:       authService,
 		ethAddress:        address,
 		txService:         txService,
 		odb:               services.odb,
 		rpcClient:         client,
-		grydSemaphore:     semaphore.NewWeighted(1),
+		grydAccess:        newAccessGuard(services.config.GRYDAccess),
+		rateLimiter:       newRateLimiter(services.config.RateLimit),
+		syncChecker:       syncChecker,
+		tracerProvider:    services.tracerProvider,
 	}
 }
 
@@ -114,6 +226,12 @@ func ServicesBootstrapper() (*BootedServices, error) {
 		return nil, fmt.Errorf("error bootstrapping logger: %w", err)
 	}
 
+	tracerProvider, err := tracing.Init(confInstance)
+	if err != nil {
+		loggerInstance.Error("failed to initialize tracing: ", err)
+		return nil, fmt.Errorf("error bootstrapping tracing: %w", err)
+	}
+
 	pgInstance, err := pg.InitPool(confInstance)
 	if err != nil {
 		loggerInstance.Error("failed to initialize pg instance: ", err)
@@ -135,24 +253,62 @@ func ServicesBootstrapper() (*BootedServices, error) {
 	}
 
 	return &BootedServices{
-		config: confInstance,
-		logger: loggerInstance,
-		pg:     pgInstance,
-		odb:    odb,
+		config:         confInstance,
+		logger:         loggerInstance,
+		pg:             pgInstance,
+		odb:            odb,
+		tracerProvider: tracerProvider,
 	}, nil
 }
 
 func (c *Container) routes() {
+	c.router.Use(c.requestIDHandler, c.accessLogHandler, c.metricsHandler, c.tracingHandler)
+
+	c.router.Route("/auth", func(r chi.Router) {
+		r.Use(c.rateLimitHandler)
+		r.Get("/nonce", c.authController.Nonce)
+		r.Post("/login", c.authController.Login)
+		r.Post("/refresh", c.authController.Refresh)
+	})
+
 	c.router.Route("/storage", func(r chi.Router) {
-		c.grydAccessHandler()
-		r.Post("/create", c.storageController.Create)
-		r.Get("/get/{id}", c.storageController.GetRecordByID)
+		r.Use(c.authenticationHandler, c.rateLimitHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.scopeHandler(auth.ScopeStorageRead))
+			r.Get("/get/{id}", c.storageController.GetRecordByID)
+			r.Get("/dataset/{datasetKey}", c.storageController.GetRecordsByDatasetKey)
+			r.Get("/query", c.storageController.QueryRecords)
+			r.Get("/wallet/{address}/datasets", c.storageController.ListByWallet)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(c.scopeHandler(auth.ScopeStorageWrite), c.drainHandler, c.grydAccessHandler)
+			r.Post("/create", c.storageController.Create)
+			r.Put("/record/{id}", c.storageController.UpdateRecord)
+			r.Delete("/record/{id}", c.storageController.DeleteRecord)
+			r.Put("/dataset/{datasetKey}", c.storageController.UpdateDataset)
+			r.Delete("/dataset/{datasetKey}", c.storageController.DeleteDataset)
+		})
 	})
 
 	c.router.Route("/balance", func(r chi.Router) {
-		c.grydAccessHandler()
+		r.Use(c.authenticationHandler, c.scopeHandler(auth.ScopeBalanceRead), c.grydAccessHandler)
 		r.Get("/get", c.storageController.GetBalance)
 	})
+
+	c.router.With(c.authenticationHandler).Get("/quota", c.storageController.GetQuota)
+
+	c.router.Get("/healthz", c.liveness)
+	c.router.Get("/readyz", c.readiness)
+	c.router.Get("/status", c.status)
+
+	c.adminRouter.Get("/queue", c.queueDepth)
+	c.adminRouter.Handle("/metrics", promhttp.Handler())
+}
+
+func (c *Container) queueDepth(w http.ResponseWriter, r *http.Request) {
+	WriteJson(w, c.grydAccess.depth(), http.StatusOK)
 }
 
 func (c *Container) cors() {
@@ -166,14 +322,16 @@ func (c *Container) cors() {
 	}))
 }
 
-func (c *Container) startServer() {
-	c.logger.Info("Starting Server at:", c.config.Address)
+func (c *Container) startServer(server *http.Server) error {
+	c.logger.Info("Starting Server at:", server.Addr)
 
-	err := http.ListenAndServe(c.config.Address, c.router)
-	if err != nil {
-		c.logger.Error("error starting server at ", c.config.Address, " with error: ", err)
-		panic(err)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.logger.Error("error starting server at ", server.Addr, " with error: ", err)
+		return fmt.Errorf("unable to serve at %s: %w", server.Addr, err)
 	}
+
+	return nil
 }
 
 func setContracts(address string, jsonABI interface{}) (common.Address, abi.ABI, error) {
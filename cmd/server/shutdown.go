@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	tracingFlushTimeout    = 5 * time.Second
+)
+
+// run serves the api and the admin endpoints until a server fails or the process is asked to stop, then shuts the
+// node down
+func (c *Container) run() error {
+	c.server = &http.Server{Addr: c.config.Address, Handler: c.router}
+
+	serveErrC := make(chan error, 2)
+	go func() {
+		serveErrC <- c.startServer(c.server)
+	}()
+
+	if len(c.config.AdminAddress) > 0 {
+		c.adminServer = &http.Server{Addr: c.config.AdminAddress, Handler: c.adminRouter}
+		go func() {
+			serveErrC <- c.startServer(c.adminServer)
+		}()
+	} else {
+		c.logger.Info("no admin address configured, the admin endpoints are not served")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var err error
+	select {
+	case sig := <-signals:
+		c.logger.Info("received ", sig, ", shutting down")
+	case err = <-serveErrC:
+		c.logger.Error("server stopped, shutting down: ", err)
+	}
+
+	c.shutdown()
+
+	return err
+}
+
+// shutdown stops accepting requests and waits for the in-flight ones up to the shutdown timeout. The background
+// workers are closed first, then orbitdb and the transaction service, postgres last as the monitor stores in it.
+func (c *Container) shutdown() {
+	timeout := c.config.Shutdown.Timeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c.drain()
+
+	err := c.server.Shutdown(ctx)
+	if err != nil {
+		c.logger.Warn("unable to shut the server down gracefully: ", err)
+	}
+
+	if c.adminServer != nil {
+		err = c.adminServer.Shutdown(ctx)
+		if err != nil {
+			c.logger.Warn("unable to shut the admin server down gracefully: ", err)
+		}
+	}
+
+	err = c.waitUploads(ctx)
+	if err != nil {
+		c.logger.Warn("closing with uploads still in flight: ", err)
+	}
+
+	for _, worker := range c.workers {
+		c.closeService("worker", worker)
+	}
+
+	c.closeService("odb", c.odb)
+	c.closeService("transaction service", *c.txService)
+	c.pg.Close()
+
+	if c.tracerProvider != nil {
+		// the spans of the shutdown itself are flushed even when the timeout was reached
+		flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+
+		err = c.tracerProvider.Shutdown(flushCtx)
+		if err != nil {
+			c.logger.Error("unable to flush spans: ", err)
+		}
+	}
+
+	c.logger.Info("shutdown complete")
+}
+
+func (c *Container) closeService(name string, service io.Closer) {
+	err := service.Close()
+	if err != nil {
+		c.logger.Error("unable to close ", name, ": ", err)
+	}
+}
+
+// drainHandler tracks the uploads in flight so the stores are not closed under them, new uploads are rejected
+// once the node is draining
+func (c *Container) drainHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.uploadsLock.Lock()
+		if c.draining {
+			c.uploadsLock.Unlock()
+			WriteJson(w, "node is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		c.uploads.Add(1)
+		c.uploadsLock.Unlock()
+
+		defer c.uploads.Done()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// drain makes the node reject new uploads
+func (c *Container) drain() {
+	c.uploadsLock.Lock()
+	defer c.uploadsLock.Unlock()
+
+	c.draining = true
+}
+
+// waitUploads waits for the uploads in flight to finish or the context to be done
+func (c *Container) waitUploads(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.uploads.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
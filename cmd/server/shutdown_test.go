@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+	"github.com/pkg/errors"
+)
+
+func TestDrainUploads(t *testing.T) {
+	t.Parallel()
+
+	testServer := newTestServer(t, testServerOptions{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := testServer.drainHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	inFlight := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(inFlight, httptest.NewRequest(http.MethodPost, "/storage/create", nil))
+		close(finished)
+	}()
+	<-started
+
+	testServer.drain()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/storage/create", nil))
+	assert.Equal(t, rr.Result().StatusCode, http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := testServer.waitUploads(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the in-flight upload to be waited for, got %v", err)
+	}
+
+	close(release)
+
+	if err := testServer.waitUploads(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-finished
+	assert.Equal(t, inFlight.Result().StatusCode, http.StatusOK)
+}
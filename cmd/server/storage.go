@@ -6,19 +6,28 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/logger"
 	"github.com/gryd-database/platform-poc/pkg/storage"
 	"github.com/gryd-database/platform-poc/pkg/transaction"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"regexp"
+	"time"
 )
 
-func New(logger *logrus.Logger, storage storage.OrbitService, dbService storage.DBService, grydContract storage.GRYDContract) *StorageController {
+var (
+	walletRegex = regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
+	txHashRegex = regexp.MustCompile("^0x([A-Fa-f0-9]{64})$")
+)
+
+func New(logger *logrus.Logger, storage storage.OrbitService, dbService storage.DBService, grydContract storage.GRYDContract, quotaLimits storage.QuotaLimits) *StorageController {
 	return &StorageController{
 		logger:      logger,
 		odbService:  storage,
 		dbService:   dbService,
 		grydService: grydContract,
+		quotaLimits: quotaLimits,
 	}
 }
 
@@ -27,102 +36,77 @@ type StorageController struct {
 	odbService  storage.OrbitService
 	grydService storage.GRYDContract
 	dbService   storage.DBService
+	quotaLimits storage.QuotaLimits
 }
 
 func (c *StorageController) Create(w http.ResponseWriter, r *http.Request) {
-	file, _, err := r.FormFile("file")
-	if err != nil {
-		c.logger.Info("unable to parse form data: ", err)
+	datasetKey := uuid.NewString()
 
-		WriteJson(w, "unable to parse form data", http.StatusInternalServerError)
+	inputDataObject, ok := c.parseDataset(w, r, datasetKey)
+	if !ok {
 		return
 	}
 
-	var inputDataObject []storage.InputData
-
-	reader := csv.NewReader(file)
-	record, err := reader.ReadAll()
-	if err != nil {
-		c.logger.Info("unable to parse form data: ", err)
-
-		WriteJson(w, "unable to parse form data", http.StatusInternalServerError)
+	wallet, ok := c.authenticatedWallet(w, r)
+	if !ok {
 		return
 	}
 
-	datasetKey := uuid.NewString()
-
 	storageVo := storage.VoStorage{
-		Wallet:     r.FormValue("wallet"),
+		Wallet:     wallet,
 		TxHash:     r.FormValue("txHash"),
 		DatasetKey: datasetKey,
 	}
 
-	for _, line := range record {
-		inputData := storage.InputData{
-			ID:         uuid.NewString(),
-			Date:       line[1],
-			DataType:   line[2],
-			Data:       line[3],
-			Dataset:    line[0],
-			DatasetKey: datasetKey,
-		}
-		inputDataObject = append(inputDataObject, inputData)
+	if !c.validateTxHash(w, r, storageVo.TxHash) {
+		return
 	}
 
-	reInput := regexp.MustCompile("^0x[0-9a-fA-F]{40}$")
-	if !(reInput.MatchString(storageVo.Wallet)) {
-		c.logger.Info("invalid wallet address:" + storageVo.Wallet)
+	// a tx pays for a single dataset, retries of an already stored tx get the existing dataset back
+	existing, err := c.dbService.GetByTxHash(r.Context(), storageVo.TxHash)
+	if err == nil {
+		logger.FromContext(r.Context(), c.logger).Info("tx hash already used: " + storageVo.TxHash)
 
-		WriteJson(w, "invalid wallet address", http.StatusBadRequest)
+		WriteJson(w, existing, http.StatusConflict)
 		return
 	}
+	if !errors.Is(err, storage.ErrStorageNotFound) {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
 
-	reInput = regexp.MustCompile("^0x([A-Fa-f0-9]{64})$")
-	if !(reInput.MatchString(storageVo.TxHash)) {
-		c.logger.Info("invalid tx hash:" + storageVo.TxHash)
-
-		WriteJson(w, "invalid tx hash", http.StatusBadRequest)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	event, err := c.grydService.VerifyEvent(r.Context(), storageVo.TxHash)
+	events, err := c.verifyEvent(r.Context(), storageVo.TxHash)
 	if err != nil {
-		if errors.Is(err, transaction.ErrEventNotFound) {
-			c.logger.Info("event not found for tx hash:" + storageVo.TxHash)
-
-			WriteJson(w, "event not found", http.StatusNotFound)
-			return
-		}
-
-		if errors.Is(err, transaction.ErrNoTopic) {
-			c.logger.Info("topic not found for tx hash:" + storageVo.TxHash)
+		c.writeVerifyEventError(w, r, storageVo.TxHash, err)
+		return
+	}
 
-			WriteJson(w, "event cannot be processed", http.StatusNotFound)
-			return
-		}
+	events = walletEvents(events, storageVo.Wallet)
+	if len(events) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("cannot verify event for tx: ", storageVo.TxHash)
 
-		if errors.Is(err, storage.ErrUnprocessableEvent) {
-			c.logger.Info("tx receipt or event does not exist for hash:" + storageVo.TxHash)
+		WriteJson(w, "cannot verify event for tx", http.StatusBadRequest)
+		return
+	}
 
-			WriteJson(w, "tx receipt or event does not exist for hash", http.StatusNotFound)
-			return
-		}
+	if !hasQueryType(events, storage.QueryTypeCreate) {
+		logger.FromContext(r.Context(), c.logger).Info("no " + storage.QueryTypeCreate + " event for tx: " + storageVo.TxHash)
 
-		c.logger.Error("internal server error: ", err)
-		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		WriteJson(w, "tx event does not allow "+storage.QueryTypeCreate, http.StatusBadRequest)
 		return
 	}
 
-	if event.User != common.HexToAddress(storageVo.Wallet) {
-		c.logger.Info("cannot verify event for tx: ", storageVo.TxHash)
-
-		WriteJson(w, "cannot verify event for tx", http.StatusBadRequest)
+	rows, bytes := int64(len(inputDataObject)), storage.RecordsSize(inputDataObject)
+	if !c.adjustQuota(w, r, wallet, rows, bytes) {
 		return
 	}
 
 	err = c.odbService.AddRecord(r.Context(), &inputDataObject)
 	if err != nil {
-		c.logger.Error("internal server error: ", err)
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.revertQuota(r, wallet, rows, bytes)
 
 		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
@@ -130,15 +114,28 @@ func (c *StorageController) Create(w http.ResponseWriter, r *http.Request) {
 
 	err = c.odbService.Ledger(r.Context(), storageVo.Wallet, datasetKey)
 	if err != nil {
-		c.logger.Error("internal server error: ", err)
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.revertQuota(r, wallet, rows, bytes)
 
 		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	resp, err := c.dbService.Create(r.Context(), &storageVo)
+	if errors.Is(err, storage.ErrDuplicateTxHash) {
+		// the rows of this request lost the race against a concurrent request for the same tx
+		_, err = c.odbService.DeleteDataset(r.Context(), datasetKey)
+		if err != nil {
+			logger.FromContext(r.Context(), c.logger).Error("unable to delete duplicate dataset "+datasetKey+": ", err)
+		}
+		c.revertQuota(r, wallet, rows, bytes)
+
+		c.writeExistingStorage(w, r, storageVo.TxHash)
+		return
+	}
 	if err != nil {
-		c.logger.Error("internal server error: ", err)
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.revertQuota(r, wallet, rows, bytes)
 
 		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
@@ -147,10 +144,25 @@ func (c *StorageController) Create(w http.ResponseWriter, r *http.Request) {
 	WriteJson(w, resp, http.StatusOK)
 }
 
+// writeExistingStorage responds with the dataset stored for a tx hash that was inserted concurrently
+func (c *StorageController) writeExistingStorage(w http.ResponseWriter, r *http.Request, txHash string) {
+	logger.FromContext(r.Context(), c.logger).Info("tx hash already used: " + txHash)
+
+	existing, err := c.dbService.GetByTxHash(r.Context(), txHash)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, existing, http.StatusConflict)
+}
+
 func (c *StorageController) GetBalance(w http.ResponseWriter, r *http.Request) {
 	balance, err := c.grydService.GetBalance(r.Context())
 	if err != nil {
-		c.logger.Error("internal server error: ", err)
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
 		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -161,17 +173,716 @@ func (c *StorageController) GetBalance(w http.ResponseWriter, r *http.Request) {
 func (c *StorageController) GetRecordByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if len(id) == 0 {
-		c.logger.Error("id is missing in path params")
+		logger.FromContext(r.Context(), c.logger).Error("id is missing in path params")
 		WriteJson(w, "id is missing in path params", http.StatusBadRequest)
 		return
 	}
 
 	record, err := c.odbService.GetRecordByID(r.Context(), id)
 	if err != nil {
-		c.logger.Error("internal server error: ", err)
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
 		WriteJson(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	WriteJson(w, record, http.StatusOK)
 }
+
+func (c *StorageController) GetRecordsByDatasetKey(w http.ResponseWriter, r *http.Request) {
+	datasetKey := chi.URLParam(r, "datasetKey")
+	if len(datasetKey) == 0 {
+		logger.FromContext(r.Context(), c.logger).Error("datasetKey is missing in path params")
+		WriteJson(w, "datasetKey is missing in path params", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid limit: ", err)
+		WriteJson(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := c.odbService.GetRecordsByDatasetKey(r.Context(), datasetKey, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(page.Records) == 0 && len(r.URL.Query().Get("cursor")) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("dataset not found: " + datasetKey)
+		WriteJson(w, "dataset not found", http.StatusNotFound)
+		return
+	}
+
+	WriteJson(w, page, http.StatusOK)
+}
+
+func (c *StorageController) QueryRecords(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := storage.RecordQuery{
+		Dataset:  params.Get("dataset"),
+		DataType: params.Get("dataType"),
+		SortBy:   params.Get("sort"),
+	}
+
+	switch params.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		logger.FromContext(r.Context(), c.logger).Info("invalid sort order: " + params.Get("order"))
+		WriteJson(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	for _, date := range []struct {
+		param string
+		value *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if len(params.Get(date.param)) == 0 {
+			continue
+		}
+
+		*date.value, err = time.Parse(time.RFC3339, params.Get(date.param))
+		if err != nil {
+			logger.FromContext(r.Context(), c.logger).Info("invalid "+date.param+" date: ", err)
+			WriteJson(w, date.param+" must be a RFC3339 date", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query.Limit, err = parseLimit(r)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid limit: ", err)
+		WriteJson(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query.Offset, err = parseOffset(r)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid offset: ", err)
+		WriteJson(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wallet, ok := c.authenticatedWallet(w, r)
+	if !ok {
+		return
+	}
+
+	// only the datasets uploaded by the wallet are queried
+	query.DatasetKeys, err = c.dbService.ListDatasetKeysByWallet(r.Context(), wallet)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(query.DatasetKeys) == 0 {
+		WriteJson(w, &storage.QueryResult{Records: []storage.InputData{}}, http.StatusOK)
+		return
+	}
+
+	result, err := c.odbService.QueryRecords(r.Context(), &query)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidSortField) {
+			logger.FromContext(r.Context(), c.logger).Info("invalid sort field: " + query.SortBy)
+			WriteJson(w, "invalid sort field", http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, storage.ErrQueryTooBroad) {
+			logger.FromContext(r.Context(), c.logger).Info("query too broad: ", err)
+			WriteJson(w, "query matches too many records, narrow it down", http.StatusBadRequest)
+			return
+		}
+
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, result, http.StatusOK)
+}
+
+func (c *StorageController) ListByWallet(w http.ResponseWriter, r *http.Request) {
+	wallet := chi.URLParam(r, "address")
+	if !walletRegex.MatchString(wallet) {
+		logger.FromContext(r.Context(), c.logger).Info("invalid wallet address:" + wallet)
+		WriteJson(w, "invalid wallet address", http.StatusBadRequest)
+		return
+	}
+
+	authenticated, ok := c.authenticatedWallet(w, r)
+	if !ok {
+		return
+	}
+
+	if common.HexToAddress(wallet) != common.HexToAddress(authenticated) {
+		logger.FromContext(r.Context(), c.logger).Info("wallet " + authenticated + " cannot list datasets of " + wallet)
+		WriteJson(w, "wallet cannot list datasets of another wallet", http.StatusForbidden)
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid limit: ", err)
+		WriteJson(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset, err := parseOffset(r)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("invalid offset: ", err)
+		WriteJson(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	datasets, err := c.dbService.ListByWallet(r.Context(), wallet, limit, offset)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, datasets, http.StatusOK)
+}
+
+func (c *StorageController) UpdateRecord(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	record, err := c.odbService.GetRecordByID(r.Context(), id)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(record.ID) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("record not found: " + id)
+		WriteJson(w, "record not found", http.StatusNotFound)
+		return
+	}
+
+	tombstoneVo, ok := c.verifyMutation(w, r, record.DatasetKey, storage.QueryTypeUpdate)
+	if !ok {
+		return
+	}
+	tombstoneVo.RecordID = record.ID
+	previousSize := storage.RecordSize(record)
+
+	// fields missing from the form keep their stored value
+	for field, value := range map[string]*string{
+		"dataset":  &record.Dataset,
+		"date":     &record.Date,
+		"dataType": &record.DataType,
+		"data":     &record.Data,
+	} {
+		if len(r.FormValue(field)) > 0 {
+			*value = r.FormValue(field)
+		}
+	}
+
+	bytes := storage.RecordSize(record) - previousSize
+	if !c.adjustQuota(w, r, tombstoneVo.Wallet, 0, bytes) {
+		return
+	}
+
+	tombstone, ok := c.claimTombstone(w, r, tombstoneVo)
+	if !ok {
+		c.revertQuota(r, tombstoneVo.Wallet, 0, bytes)
+		return
+	}
+
+	err = c.odbService.UpdateRecord(r.Context(), record)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.releaseTombstone(r, tombstone)
+		c.revertQuota(r, tombstoneVo.Wallet, 0, bytes)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, record, http.StatusOK)
+}
+
+func (c *StorageController) DeleteRecord(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	record, err := c.odbService.GetRecordByID(r.Context(), id)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(record.ID) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("record not found: " + id)
+		WriteJson(w, "record not found", http.StatusNotFound)
+		return
+	}
+
+	tombstoneVo, ok := c.verifyMutation(w, r, record.DatasetKey, storage.QueryTypeDelete)
+	if !ok {
+		return
+	}
+	tombstoneVo.RecordID = record.ID
+
+	tombstone, ok := c.claimTombstone(w, r, tombstoneVo)
+	if !ok {
+		return
+	}
+
+	err = c.odbService.DeleteRecord(r.Context(), record.ID)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.releaseTombstone(r, tombstone)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	c.revertQuota(r, tombstoneVo.Wallet, 1, storage.RecordSize(record))
+
+	WriteJson(w, tombstone, http.StatusOK)
+}
+
+func (c *StorageController) UpdateDataset(w http.ResponseWriter, r *http.Request) {
+	datasetKey := chi.URLParam(r, "datasetKey")
+
+	inputDataObject, ok := c.parseDataset(w, r, datasetKey)
+	if !ok {
+		return
+	}
+
+	tombstoneVo, ok := c.verifyMutation(w, r, datasetKey, storage.QueryTypeUpdate)
+	if !ok {
+		return
+	}
+
+	existing, err := c.odbService.GetRecordsByDatasetKey(r.Context(), datasetKey, "", 0)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rows := int64(len(inputDataObject) - len(existing.Records))
+	bytes := storage.RecordsSize(inputDataObject) - storage.RecordsSize(existing.Records)
+	if !c.adjustQuota(w, r, tombstoneVo.Wallet, rows, bytes) {
+		return
+	}
+
+	tombstone, ok := c.claimTombstone(w, r, tombstoneVo)
+	if !ok {
+		c.revertQuota(r, tombstoneVo.Wallet, rows, bytes)
+		return
+	}
+
+	err = c.odbService.ReplaceDataset(r.Context(), datasetKey, &inputDataObject)
+	if err != nil {
+		c.reconcileQuota(r, tombstoneVo.Wallet, datasetKey, int64(len(inputDataObject)), storage.RecordsSize(inputDataObject))
+
+		if errors.Is(err, storage.ErrPartialMutation) {
+			// the tx paid for a change that was partly applied, its tombstone is kept
+			logger.FromContext(r.Context(), c.logger).Error("dataset "+datasetKey+" partially updated: ", err)
+			WriteJson(w, "dataset partially updated", http.StatusInternalServerError)
+			return
+		}
+
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.releaseTombstone(r, tombstone)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, tombstone, http.StatusOK)
+}
+
+func (c *StorageController) DeleteDataset(w http.ResponseWriter, r *http.Request) {
+	datasetKey := chi.URLParam(r, "datasetKey")
+
+	tombstoneVo, ok := c.verifyMutation(w, r, datasetKey, storage.QueryTypeDelete)
+	if !ok {
+		return
+	}
+
+	existing, err := c.odbService.GetRecordsByDatasetKey(r.Context(), datasetKey, "", 0)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	tombstone, ok := c.claimTombstone(w, r, tombstoneVo)
+	if !ok {
+		return
+	}
+
+	_, err = c.odbService.DeleteDataset(r.Context(), datasetKey)
+	if errors.Is(err, storage.ErrPartialMutation) {
+		// the tx paid for a change that was partly applied, its tombstone is kept
+		logger.FromContext(r.Context(), c.logger).Error("dataset "+datasetKey+" partially deleted: ", err)
+		c.reconcileQuota(r, tombstoneVo.Wallet, datasetKey, int64(len(existing.Records)), storage.RecordsSize(existing.Records))
+		WriteJson(w, "dataset partially deleted", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		c.releaseTombstone(r, tombstone)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	c.revertQuota(r, tombstoneVo.Wallet, int64(len(existing.Records)), storage.RecordsSize(existing.Records))
+
+	WriteJson(w, tombstone, http.StatusOK)
+}
+
+// claimTombstone stores the tombstone before the dataset is mutated so a tx hash pays for a single mutation,
+// the response is written when the tx hash was claimed by a concurrent request or the tombstone cannot be stored
+func (c *StorageController) claimTombstone(w http.ResponseWriter, r *http.Request, tombstoneVo *storage.VoTombstone) (*storage.DTOTombstone, bool) {
+	tombstone, err := c.dbService.CreateTombstone(r.Context(), tombstoneVo)
+	if errors.Is(err, storage.ErrDuplicateTxHash) {
+		logger.FromContext(r.Context(), c.logger).Info("tx hash already used: " + tombstoneVo.TxHash)
+
+		WriteJson(w, "tx hash already used", http.StatusConflict)
+		return nil, false
+	}
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return tombstone, true
+}
+
+// releaseTombstone removes the tombstone of a mutation that could not be applied so its tx can be retried,
+// failures are only logged as the outcome of the request does not depend on them
+func (c *StorageController) releaseTombstone(r *http.Request, tombstone *storage.DTOTombstone) {
+	err := c.dbService.DeleteTombstone(r.Context(), tombstone.ID)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to release tombstone of tx "+tombstone.TxHash+": ", err)
+	}
+}
+
+// txHashUnused checks that the tx hash did not already pay for a dataset or a mutation, the response is written when it did
+func (c *StorageController) txHashUnused(w http.ResponseWriter, r *http.Request, txHash string) bool {
+	_, err := c.dbService.GetByTxHash(r.Context(), txHash)
+	if err == nil {
+		logger.FromContext(r.Context(), c.logger).Info("tx hash already used: " + txHash)
+
+		WriteJson(w, "tx hash already used", http.StatusConflict)
+		return false
+	}
+	if !errors.Is(err, storage.ErrStorageNotFound) {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	_, err = c.dbService.GetTombstoneByTxHash(r.Context(), txHash)
+	if err == nil {
+		logger.FromContext(r.Context(), c.logger).Info("tx hash already used: " + txHash)
+
+		WriteJson(w, "tx hash already used", http.StatusConflict)
+		return false
+	}
+	if !errors.Is(err, storage.ErrTombstoneNotFound) {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// verifyMutation checks that the unused tx of the request emitted an event of the given query type for the
+// wallet that owns the dataset in the ledger, the response is written when the check fails
+func (c *StorageController) verifyMutation(w http.ResponseWriter, r *http.Request, datasetKey, queryType string) (*storage.VoTombstone, bool) {
+	wallet, ok := c.authenticatedWallet(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	tombstoneVo := &storage.VoTombstone{
+		Wallet:     wallet,
+		TxHash:     r.FormValue("txHash"),
+		DatasetKey: datasetKey,
+		QueryType:  queryType,
+	}
+
+	if !c.validateTxHash(w, r, tombstoneVo.TxHash) {
+		return nil, false
+	}
+
+	if !c.txHashUnused(w, r, tombstoneVo.TxHash) {
+		return nil, false
+	}
+
+	events, err := c.verifyEvent(r.Context(), tombstoneVo.TxHash)
+	if err != nil {
+		c.writeVerifyEventError(w, r, tombstoneVo.TxHash, err)
+		return nil, false
+	}
+
+	events = walletEvents(events, tombstoneVo.Wallet)
+	if len(events) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("cannot verify event for tx: ", tombstoneVo.TxHash)
+
+		WriteJson(w, "cannot verify event for tx", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !hasQueryType(events, queryType) {
+		logger.FromContext(r.Context(), c.logger).Info("no " + queryType + " event for tx: " + tombstoneVo.TxHash)
+
+		WriteJson(w, "tx event does not allow "+queryType, http.StatusBadRequest)
+		return nil, false
+	}
+
+	ledger, err := c.odbService.GetWalletByDatasetKey(r.Context(), datasetKey)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if len(ledger.Key) == 0 {
+		logger.FromContext(r.Context(), c.logger).Info("dataset not found: " + datasetKey)
+		WriteJson(w, "dataset not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if common.HexToAddress(ledger.Wallet) != common.HexToAddress(tombstoneVo.Wallet) {
+		logger.FromContext(r.Context(), c.logger).Info("wallet " + tombstoneVo.Wallet + " does not own dataset: " + datasetKey)
+		WriteJson(w, "wallet does not own dataset", http.StatusForbidden)
+		return nil, false
+	}
+
+	return tombstoneVo, true
+}
+
+func (c *StorageController) GetQuota(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := c.authenticatedWallet(w, r)
+	if !ok {
+		return
+	}
+
+	quota, err := c.dbService.GetQuota(r.Context(), wallet)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	WriteJson(w, struct {
+		*storage.DTOQuota
+		storage.QuotaLimits
+	}{quota, c.quotaLimits}, http.StatusOK)
+}
+
+// adjustQuota adds the rows and bytes to the quota usage of the wallet, the response is written when the quota is exceeded
+func (c *StorageController) adjustQuota(w http.ResponseWriter, r *http.Request, wallet string, rows, bytes int64) bool {
+	_, err := c.dbService.AdjustQuota(r.Context(), wallet, rows, bytes, c.quotaLimits)
+	if err != nil {
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			logger.FromContext(r.Context(), c.logger).Info("storage quota exceeded for wallet: " + wallet)
+
+			WriteJson(w, "storage quota exceeded", http.StatusForbidden)
+			return false
+		}
+
+		logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+		WriteJson(w, "internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// revertQuota removes the rows and bytes from the quota usage of the wallet, failures are only logged
+// as the outcome of the request does not depend on them
+func (c *StorageController) revertQuota(r *http.Request, wallet string, rows, bytes int64) {
+	_, err := c.dbService.AdjustQuota(r.Context(), wallet, -rows, -bytes, c.quotaLimits)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to revert quota of wallet "+wallet+": ", err)
+	}
+}
+
+// reconcileQuota sets the usage the quota counts for a dataset, accountedRows and accountedBytes, to the records
+// that are actually stored after a change of the dataset failed, failures are only logged
+func (c *StorageController) reconcileQuota(r *http.Request, wallet, datasetKey string, accountedRows, accountedBytes int64) {
+	stored, err := c.odbService.GetRecordsByDatasetKey(r.Context(), datasetKey, "", 0)
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to recompute quota of wallet "+wallet+": ", err)
+		return
+	}
+
+	rows := int64(len(stored.Records)) - accountedRows
+	bytes := storage.RecordsSize(stored.Records) - accountedBytes
+	if rows == 0 && bytes == 0 {
+		return
+	}
+
+	// the records are stored already, the limits must not prevent the quota from counting them
+	_, err = c.dbService.AdjustQuota(r.Context(), wallet, rows, bytes, storage.QuotaLimits{})
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Error("unable to recompute quota of wallet "+wallet+": ", err)
+	}
+}
+
+// authenticatedWallet returns the address of the signed-in wallet, the response is written when the request is not authenticated
+func (c *StorageController) authenticatedWallet(w http.ResponseWriter, r *http.Request) (string, bool) {
+	address, ok := auth.AddressFromContext(r.Context())
+	if !ok {
+		logger.FromContext(r.Context(), c.logger).Info("request is not authenticated")
+
+		WriteJson(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+
+	return address.Hex(), true
+}
+
+// validateTxHash checks the format of the tx hash form value, the response is written when it is invalid.
+// The logs of the request carry the tx hash from then on.
+func (c *StorageController) validateTxHash(w http.ResponseWriter, r *http.Request, txHash string) bool {
+	logger.AddFields(r.Context(), logrus.Fields{"txHash": txHash})
+
+	if !txHashRegex.MatchString(txHash) {
+		logger.FromContext(r.Context(), c.logger).Info("invalid tx hash:" + txHash)
+
+		WriteJson(w, "invalid tx hash", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func (c *StorageController) writeVerifyEventError(w http.ResponseWriter, r *http.Request, txHash string, err error) {
+	if errors.Is(err, transaction.ErrEventNotFound) {
+		logger.FromContext(r.Context(), c.logger).Info("event not found for tx hash:" + txHash)
+
+		WriteJson(w, "event not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, transaction.ErrTransactionReverted) {
+		logger.FromContext(r.Context(), c.logger).Info("tx reverted for hash:" + txHash)
+
+		WriteJson(w, "tx reverted", http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, transaction.ErrNoTopic) {
+		logger.FromContext(r.Context(), c.logger).Info("topic not found for tx hash:" + txHash)
+
+		WriteJson(w, "event cannot be processed", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, storage.ErrEventNotIndexed) {
+		logger.FromContext(r.Context(), c.logger).Info("event not indexed yet for tx hash:" + txHash)
+
+		WriteJson(w, "event not indexed yet, retry once the tx is confirmed", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, storage.ErrEventNotConfirmed) {
+		logger.FromContext(r.Context(), c.logger).Info("tx not confirmed yet for hash:" + txHash)
+
+		WriteJson(w, "tx not confirmed yet, retry once it is confirmed", http.StatusTooEarly)
+		return
+	}
+
+	if errors.Is(err, storage.ErrBlockNotCanonical) {
+		logger.FromContext(r.Context(), c.logger).Info("block of tx is no longer canonical for hash:" + txHash)
+
+		WriteJson(w, "tx block is no longer canonical", http.StatusConflict)
+		return
+	}
+
+	if errors.Is(err, storage.ErrChainNotSynced) {
+		logger.FromContext(r.Context(), c.logger).Warn("refusing to verify tx while the chain backend is lagging, hash:" + txHash + " error: " + err.Error())
+
+		WriteJson(w, "chain backend not synced, retry later", http.StatusServiceUnavailable)
+		return
+	}
+
+	if errors.Is(err, storage.ErrUnprocessableEvent) {
+		logger.FromContext(r.Context(), c.logger).Info("tx receipt or event does not exist for hash:" + txHash)
+
+		WriteJson(w, "tx receipt or event does not exist for hash", http.StatusNotFound)
+		return
+	}
+
+	logger.FromContext(r.Context(), c.logger).Error("internal server error: ", err)
+	WriteJson(w, "internal server error", http.StatusInternalServerError)
+}
+
+// walletEvents returns the events of a tx emitted for the wallet
+func walletEvents(events []storage.EventInsertDataSuccess, wallet string) []storage.EventInsertDataSuccess {
+	var matching []storage.EventInsertDataSuccess
+	for _, event := range events {
+		if event.User == common.HexToAddress(wallet) {
+			matching = append(matching, event)
+		}
+	}
+
+	return matching
+}
+
+func hasQueryType(events []storage.EventInsertDataSuccess, queryType string) bool {
+	for _, event := range events {
+		if event.QueryType == queryType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseDataset reads the csv of the file form value into records of the given dataset, the response is written when it cannot be parsed
+func (c *StorageController) parseDataset(w http.ResponseWriter, r *http.Request, datasetKey string) ([]storage.InputData, bool) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("unable to parse form data: ", err)
+
+		WriteJson(w, "unable to parse form data", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	reader := csv.NewReader(file)
+	record, err := reader.ReadAll()
+	if err != nil {
+		logger.FromContext(r.Context(), c.logger).Info("unable to parse form data: ", err)
+
+		WriteJson(w, "unable to parse form data", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	inputDataObject := make([]storage.InputData, 0, len(record))
+	for _, line := range record {
+		if len(line) < 4 {
+			logger.FromContext(r.Context(), c.logger).Info("invalid csv line: ", line)
+
+			WriteJson(w, "csv lines must contain dataset, date, dataType and data", http.StatusBadRequest)
+			return nil, false
+		}
+
+		inputData := storage.InputData{
+			ID:         uuid.NewString(),
+			Date:       line[1],
+			DataType:   line[2],
+			Data:       line[3],
+			Dataset:    line[0],
+			DatasetKey: datasetKey,
+		}
+		inputDataObject = append(inputDataObject, inputData)
+	}
+
+	return inputDataObject, true
+}
@@ -3,20 +3,26 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/gryd-database/platform-poc/pkg/auth/authMock"
 	"github.com/gryd-database/platform-poc/pkg/storage"
 	"github.com/gryd-database/platform-poc/pkg/storage/dbMock"
 	"github.com/gryd-database/platform-poc/pkg/storage/grydContractMock"
 	"github.com/gryd-database/platform-poc/pkg/storage/odbMock"
 	"github.com/magiconair/properties/assert"
+	"github.com/sirupsen/logrus/hooks/test"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -33,20 +39,27 @@ func TestCreateStorage(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		t.Parallel()
 
-		event := &storage.EventInsertDataSuccess{
-			User:      common.HexToAddress(address),
-			QueryType: "create",
+		// a batch payment emits an event per wallet
+		events := []storage.EventInsertDataSuccess{
+			{User: common.HexToAddress("0x0000000000000000000000000000000000000001"), QueryType: "create"},
+			{User: common.HexToAddress(address), QueryType: "create"},
 		}
 
 		id, _ := uuid.NewUUID()
 		datasetKey := uuid.NewString()
 
 		contract := grydContractMock.New(
-			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) (*storage.EventInsertDataSuccess, error) {
-				return event, nil
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return events, nil
 			}))
 
 		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+				return &storage.DTOQuota{Wallet: wallet, RowsStored: rows, BytesStored: bytes}, nil
+			}),
 			dbMock.WithCreate(func(ctx context.Context, voStorage *storage.VoStorage) (*storage.DTOStorage, error) {
 				return &storage.DTOStorage{
 					ID:         id,
@@ -70,7 +83,140 @@ func TestCreateStorage(t *testing.T) {
 		//prepare the reader instances to encode
 		v := map[string]io.Reader{
 			"file":   mustOpen("../../sampleData.csv"),
-			"wallet": strings.NewReader(address),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, createStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("replayed tx hash", func(t *testing.T) {
+		t.Parallel()
+
+		contract := grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return nil, errors.New("event must not be verified for a used tx hash")
+			}))
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, hash string) (*storage.DTOStorage, error) {
+				return &storage.DTOStorage{
+					Wallet:     address,
+					TxHash:     hash,
+					CreatedAt:  time.Now(),
+					DatasetKey: uuid.NewString(),
+				}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbMock.New(), dbServiceOpts: dbService, grydContractServiceOpts: contract})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, createStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusConflict)
+	})
+
+	t.Run("no create event", func(t *testing.T) {
+		t.Parallel()
+
+		contract := grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return []storage.EventInsertDataSuccess{{User: common.HexToAddress(address), QueryType: storage.QueryTypeDelete}}, nil
+			}))
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+				return nil, errors.New("quota must not be used without a create event")
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbMock.New(), dbServiceOpts: dbService, grydContractServiceOpts: contract})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, createStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		contract := grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return []storage.EventInsertDataSuccess{{User: common.HexToAddress(address), QueryType: "create"}}, nil
+			}))
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+				return nil, storage.ErrQuotaExceeded
+			}))
+
+		odbService := odbMock.New(
+			odbMock.WithAddRecord(func(ctx context.Context, storage *[]storage.InputData) error {
+				return errors.New("records must not be stored when the quota is exceeded")
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbService, dbServiceOpts: dbService, grydContractServiceOpts: contract})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, createStorage())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbMock.New(), dbServiceOpts: dbMock.New()})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
 			"txHash": strings.NewReader(txHash.String()),
 		}
 
@@ -83,8 +229,652 @@ func TestCreateStorage(t *testing.T) {
 
 		testServer.router.ServeHTTP(rr, req)
 
+		assert.Equal(t, rr.Result().StatusCode, http.StatusUnauthorized)
+	})
+}
+
+func TestGetRecordsByDatasetKey(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+	datasetKey := uuid.NewString()
+	getDataset := func(query string) string {
+		return fmt.Sprintf("/storage/dataset/%s%s", datasetKey, query)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		odbService := odbMock.New(
+			odbMock.WithGetRecordsByDatasetKey(func(ctx context.Context, key, cursor string, limit int) (*storage.DatasetPage, error) {
+				if key != datasetKey || cursor != "abc" || limit != 10 {
+					return nil, fmt.Errorf("unexpected arguments: %s %s %d", key, cursor, limit)
+				}
+				return &storage.DatasetPage{
+					Records:    []storage.InputData{{ID: "abd", DatasetKey: datasetKey}},
+					NextCursor: "abd",
+				}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbService})
+
+		req := httptest.NewRequest(http.MethodGet, getDataset("?cursor=abc&limit=10"), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		odbService := odbMock.New(
+			odbMock.WithGetRecordsByDatasetKey(func(ctx context.Context, key, cursor string, limit int) (*storage.DatasetPage, error) {
+				return &storage.DatasetPage{}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbService})
+
+		req := httptest.NewRequest(http.MethodGet, getDataset(""), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, getDataset("?limit=0"), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+}
+
+func TestQueryRecords(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+	datasetKey := uuid.NewString()
+
+	dbService := dbMock.New(
+		dbMock.WithListDatasetKeysByWallet(func(ctx context.Context, wallet string) ([]string, error) {
+			if wallet != address {
+				return []string{}, nil
+			}
+			return []string{datasetKey}, nil
+		}))
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		odbService := odbMock.New(
+			odbMock.WithQueryRecords(func(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error) {
+				if query.Dataset != "sensor1" || query.DataType != "Temperature" || !query.Desc || query.Offset != 5 || query.From.IsZero() {
+					return nil, fmt.Errorf("unexpected query: %+v", query)
+				}
+				if len(query.DatasetKeys) != 1 || query.DatasetKeys[0] != datasetKey {
+					return nil, fmt.Errorf("unexpected dataset keys: %v", query.DatasetKeys)
+				}
+				return &storage.QueryResult{Records: []storage.InputData{{Dataset: "sensor1"}}, Total: 6}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService, odbServiceOpts: odbService})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/query?dataset=sensor1&dataType=Temperature&from=2023-07-10T00:00:00Z&order=desc&offset=5", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("no datasets", func(t *testing.T) {
+		t.Parallel()
+
+		// the docstore must not be queried for a wallet without datasets
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService, odbServiceOpts: odbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/query", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, "0x0000000000000000000000000000000000000001"))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+		var result storage.QueryResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, result.Total, 0)
+		assert.Equal(t, len(result.Records), 0)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/query?to=yesterday", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("invalid sort field", func(t *testing.T) {
+		t.Parallel()
+
+		odbService := odbMock.New(
+			odbMock.WithQueryRecords(func(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error) {
+				return nil, storage.ErrInvalidSortField
+			}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService, odbServiceOpts: odbService})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/query?sort=wallet", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("too broad", func(t *testing.T) {
+		t.Parallel()
+
+		odbService := odbMock.New(
+			odbMock.WithQueryRecords(func(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error) {
+				return nil, fmt.Errorf("%w: more than 10000 records", storage.ErrQueryTooBroad)
+			}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService, odbServiceOpts: odbService})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/query", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+}
+
+func TestDeleteRecord(t *testing.T) {
+	t.Parallel()
+
+	txHash := common.HexToHash("0xcb0caeff88b8bda3656396b19b808cd8b35c0054e96553852441ea2c3f5f4d26")
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+	recordID := uuid.NewString()
+	datasetKey := uuid.NewString()
+	deleteRecord := func() string {
+		return fmt.Sprintf("/storage/record/%s?txHash=%s", recordID, txHash.String())
+	}
+
+	newOdbService := func(owner string) storage.OrbitService {
+		return odbMock.New(
+			odbMock.WithGetRecordByID(func(ctx context.Context, id string) (*storage.InputData, error) {
+				if id != recordID {
+					return &storage.InputData{}, nil
+				}
+				return &storage.InputData{ID: recordID, DatasetKey: datasetKey}, nil
+			}),
+			odbMock.WithGetWalletByDatasetKey(func(ctx context.Context, key string) (*storage.Ledger, error) {
+				return &storage.Ledger{Key: key, Wallet: owner}, nil
+			}),
+			odbMock.WithDeleteRecord(func(ctx context.Context, id string) error {
+				return nil
+			}))
+	}
+
+	// the record must stay in place when the tx hash cannot be claimed
+	untouchedOdbService := odbMock.New(
+		odbMock.WithGetRecordByID(func(ctx context.Context, id string) (*storage.InputData, error) {
+			return &storage.InputData{ID: recordID, DatasetKey: datasetKey}, nil
+		}),
+		odbMock.WithGetWalletByDatasetKey(func(ctx context.Context, key string) (*storage.Ledger, error) {
+			return &storage.Ledger{Key: key, Wallet: address}, nil
+		}),
+		odbMock.WithDeleteRecord(func(ctx context.Context, id string) error {
+			return errors.New("record must not be deleted with a used tx hash")
+		}))
+
+	newContract := func(queryType string) storage.GRYDContract {
+		return grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return []storage.EventInsertDataSuccess{{User: common.HexToAddress(address), QueryType: queryType}}, nil
+			}))
+	}
+
+	dbService := dbMock.New(
+		dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+			return nil, storage.ErrStorageNotFound
+		}),
+		dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+			return nil, storage.ErrTombstoneNotFound
+		}),
+		dbMock.WithCreateTombstone(func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+			if voTombstone.RecordID != recordID || voTombstone.QueryType != storage.QueryTypeDelete {
+				return nil, fmt.Errorf("unexpected tombstone: %+v", voTombstone)
+			}
+			return &storage.DTOTombstone{RecordID: recordID, DatasetKey: datasetKey}, nil
+		}),
+		dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+			if rows != -1 {
+				return nil, fmt.Errorf("unexpected rows: %d", rows)
+			}
+			return &storage.DTOQuota{Wallet: wallet}, nil
+		}))
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          newOdbService(address),
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
 		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
 	})
+
+	t.Run("wrong query type", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          newOdbService(address),
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeCreate)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("reused tx hash", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, hash string) (*storage.DTOTombstone, error) {
+				return &storage.DTOTombstone{TxHash: hash, DatasetKey: datasetKey, QueryType: storage.QueryTypeDelete}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          untouchedOdbService,
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusConflict)
+	})
+
+	t.Run("tx hash claimed concurrently", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+				return nil, storage.ErrTombstoneNotFound
+			}),
+			dbMock.WithCreateTombstone(func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+				return nil, fmt.Errorf("unable to store tombstone in db: %w", storage.ErrDuplicateTxHash)
+			}))
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          untouchedOdbService,
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusConflict)
+	})
+
+	t.Run("delete fails", func(t *testing.T) {
+		t.Parallel()
+
+		var released bool
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+				return nil, storage.ErrTombstoneNotFound
+			}),
+			dbMock.WithCreateTombstone(func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+				return &storage.DTOTombstone{RecordID: recordID, DatasetKey: datasetKey, TxHash: voTombstone.TxHash}, nil
+			}),
+			dbMock.WithDeleteTombstone(func(ctx context.Context, id uuid.UUID) error {
+				released = true
+				return nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          untouchedOdbService,
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusInternalServerError)
+		assert.Equal(t, released, true)
+	})
+
+	t.Run("not owner", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          newOdbService("0x0000000000000000000000000000000000000001"),
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, deleteRecord(), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("record not found", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{
+			odbServiceOpts:          newOdbService(address),
+			dbServiceOpts:           dbService,
+			grydContractServiceOpts: newContract(storage.QueryTypeDelete)})
+
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/storage/record/%s", uuid.NewString()), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusNotFound)
+	})
+}
+
+func TestUpdateDataset(t *testing.T) {
+	t.Parallel()
+
+	txHash := common.HexToHash("0xcb0caeff88b8bda3656396b19b808cd8b35c0054e96553852441ea2c3f5f4d26")
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+	datasetKey := uuid.NewString()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		contract := grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return []storage.EventInsertDataSuccess{{User: common.HexToAddress(address), QueryType: storage.QueryTypeUpdate}}, nil
+			}))
+
+		odbService := odbMock.New(
+			odbMock.WithGetWalletByDatasetKey(func(ctx context.Context, key string) (*storage.Ledger, error) {
+				return &storage.Ledger{Key: key, Wallet: address}, nil
+			}),
+			odbMock.WithGetRecordsByDatasetKey(func(ctx context.Context, key, cursor string, limit int) (*storage.DatasetPage, error) {
+				return &storage.DatasetPage{Records: []storage.InputData{{ID: "abc", DatasetKey: key}}}, nil
+			}),
+			odbMock.WithReplaceDataset(func(ctx context.Context, key string, records *[]storage.InputData) error {
+				for _, record := range *records {
+					if record.DatasetKey != datasetKey {
+						return fmt.Errorf("unexpected dataset key: %s", record.DatasetKey)
+					}
+				}
+				return nil
+			}))
+
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+				return nil, storage.ErrTombstoneNotFound
+			}),
+			dbMock.WithCreateTombstone(func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+				return &storage.DTOTombstone{DatasetKey: datasetKey, QueryType: voTombstone.QueryType}, nil
+			}),
+			dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+				return &storage.DTOQuota{Wallet: wallet}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbService, dbServiceOpts: dbService, grydContractServiceOpts: contract})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, fmt.Sprintf("/storage/dataset/%s", datasetKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Method = http.MethodPut
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("partially updated", func(t *testing.T) {
+		t.Parallel()
+
+		contract := grydContractMock.New(
+			grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+				return []storage.EventInsertDataSuccess{{User: common.HexToAddress(address), QueryType: storage.QueryTypeUpdate}}, nil
+			}))
+
+		// the previous record is still stored next to the new ones
+		stored := []storage.InputData{{ID: "abc", DatasetKey: datasetKey, Data: "previous"}}
+		odbService := odbMock.New(
+			odbMock.WithGetWalletByDatasetKey(func(ctx context.Context, key string) (*storage.Ledger, error) {
+				return &storage.Ledger{Key: key, Wallet: address}, nil
+			}),
+			odbMock.WithGetRecordsByDatasetKey(func(ctx context.Context, key, cursor string, limit int) (*storage.DatasetPage, error) {
+				return &storage.DatasetPage{Records: stored}, nil
+			}),
+			odbMock.WithReplaceDataset(func(ctx context.Context, key string, records *[]storage.InputData) error {
+				stored = append(stored, *records...)
+				return fmt.Errorf("%w: 0 of 1 previous records deleted: connection refused", storage.ErrPartialMutation)
+			}))
+
+		var (
+			lock      sync.Mutex
+			usedRows  int64
+			usedBytes int64
+		)
+		dbService := dbMock.New(
+			dbMock.WithGetByTxHash(func(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+				return nil, storage.ErrStorageNotFound
+			}),
+			dbMock.WithGetTombstoneByTxHash(func(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+				return nil, storage.ErrTombstoneNotFound
+			}),
+			dbMock.WithCreateTombstone(func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+				return &storage.DTOTombstone{DatasetKey: datasetKey, QueryType: voTombstone.QueryType}, nil
+			}),
+			dbMock.WithDeleteTombstone(func(ctx context.Context, id uuid.UUID) error {
+				return errors.New("the tombstone of a partly applied change must be kept")
+			}),
+			dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				usedRows, usedBytes = usedRows+rows, usedBytes+bytes
+				return &storage.DTOQuota{Wallet: wallet, RowsStored: usedRows, BytesStored: usedBytes}, nil
+			}))
+
+		logger, hook := test.NewNullLogger()
+		testServer := newTestServer(t, testServerOptions{odbServiceOpts: odbService, dbServiceOpts: dbService, grydContractServiceOpts: contract, logger: logger})
+
+		v := map[string]io.Reader{
+			"file":   mustOpen("../../sampleData.csv"),
+			"txHash": strings.NewReader(txHash.String()),
+		}
+
+		req, err := Upload(v, fmt.Sprintf("/storage/dataset/%s", datasetKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Method = http.MethodPut
+
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusInternalServerError)
+
+		for _, entry := range hook.AllEntries() {
+			if strings.Contains(entry.Message, "release tombstone") {
+				t.Fatalf("unexpected release of the tombstone: %s", entry.Message)
+			}
+		}
+
+		// the quota changes by what is stored now compared to the single previous record
+		assert.Equal(t, usedRows, int64(len(stored)-1))
+		assert.Equal(t, usedBytes, storage.RecordsSize(stored)-storage.RecordsSize(stored[:1]))
+	})
+}
+
+func TestListByWallet(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(
+			dbMock.WithListByWallet(func(ctx context.Context, wallet string, limit, offset int) ([]storage.DTOStorage, error) {
+				if wallet != address || limit != 20 || offset != 40 {
+					return nil, fmt.Errorf("unexpected arguments: %s %d %d", wallet, limit, offset)
+				}
+				return []storage.DTOStorage{{Wallet: address, DatasetKey: uuid.NewString(), CreatedAt: time.Now()}}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService})
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/storage/wallet/%s/datasets?limit=20&offset=40", address), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("invalid wallet", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, "/storage/wallet/0x123/datasets", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		t.Parallel()
+
+		authService := authMock.New(authMock.WithAuthenticate(func(token string) (*auth.Claims, error) {
+			return &auth.Claims{Subject: address, Type: auth.TokenTypeAccess, Scopes: []string{auth.ScopeBalanceRead}}, nil
+		}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbMock.New(), authServiceOpts: authService})
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/storage/wallet/%s/datasets", address), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
+	})
+
+	t.Run("other wallet", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/storage/wallet/%s/datasets", address), nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, "0x0000000000000000000000000000000000000001"))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusForbidden)
+	})
+}
+
+func TestGetQuota(t *testing.T) {
+	t.Parallel()
+
+	address := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(
+			dbMock.WithGetQuota(func(ctx context.Context, wallet string) (*storage.DTOQuota, error) {
+				if wallet != address {
+					return nil, fmt.Errorf("unexpected wallet: %s", wallet)
+				}
+				return &storage.DTOQuota{Wallet: wallet, RowsStored: 10, BytesStored: 512}, nil
+			}))
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbService})
+
+		req := httptest.NewRequest(http.MethodGet, "/quota", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, authenticate(req, address))
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		t.Parallel()
+
+		testServer := newTestServer(t, testServerOptions{dbServiceOpts: dbMock.New()})
+
+		req := httptest.NewRequest(http.MethodGet, "/quota", nil)
+		rr := httptest.NewRecorder()
+
+		testServer.router.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Result().StatusCode, http.StatusUnauthorized)
+	})
 }
 
 func Upload(values map[string]io.Reader, url string) (req *http.Request, err error) {
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanRecorder    = tracetest.NewSpanRecorder()
+	installRecorder sync.Once
+)
+
+// recordSpans installs the span recorder as global tracer provider, tracers obtained from the global provider stay
+// bound to the first provider installed so it is only installed once
+func recordSpans() *tracetest.SpanRecorder {
+	installRecorder.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return spanRecorder
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordSpans()
+	testServer := newTestServer(t, testServerOptions{})
+
+	var traceID trace.TraceID
+	if _, err := rand.Read(traceID[:]); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("traceparent", "00-"+traceID.String()+"-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	testServer.router.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Result().StatusCode, http.StatusOK)
+
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) != 1 {
+		t.Fatalf("expected a single span in the trace of the caller, got %d", len(spans))
+	}
+
+	span := spans[0]
+	assert.Equal(t, span.Name(), "GET /healthz")
+
+	var status int64
+	for _, a := range span.Attributes() {
+		if a.Key == semconv.HTTPStatusCodeKey {
+			status = a.Value.AsInt64()
+		}
+	}
+	assert.Equal(t, status, int64(http.StatusOK))
+}
@@ -4,6 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
 )
 
 func WriteJson(w http.ResponseWriter, v interface{}, statusCode int) {
@@ -24,3 +30,33 @@ func WriteJson(w http.ResponseWriter, v interface{}, statusCode int) {
 		return
 	}
 }
+
+// parseLimit reads the limit query param, falling back to defaultPageLimit when it is absent
+func parseLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if len(value) == 0 {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 || limit > maxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	return limit, nil
+}
+
+// parseOffset reads the offset query param, an absent offset starts at the first item
+func parseOffset(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("offset")
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("offset must be a positive number")
+	}
+
+	return offset, nil
+}
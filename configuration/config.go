@@ -1,12 +1,18 @@
 package configuration
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
+	"time"
 )
 
+// Config is the configuration of the node, AdminAddress is where the operator endpoints are served, they are not
+// served at all when it is empty
 type Config struct {
-	Address  string `mapstructure:"ADDRESS"`
-	Postgres struct {
+	Address      string `mapstructure:"ADDRESS"`
+	AdminAddress string `mapstructure:"ADMIN_ADDRESS"`
+	JWTSecret    string `mapstructure:"JWTSECRET"`
+	Postgres     struct {
 		Host       string `mapstructure:"DB_HOST"`
 		Password   string `mapstructure:"DB_PASSWORD"`
 		Port       string `mapstructure:"DB_PORT"`
@@ -20,17 +26,145 @@ type Config struct {
 		IsReplicated bool   `mapstructure:"ISREPLICATED"`
 		Address      string `mapstructure:"ADDRESS"`
 	} `mapstructure:"IPFS"`
-	Logger struct {
-		LogLevel string `mapstructure:"LOG_LEVEL"`
-		LogEnv   string `mapstructure:"LOG_ENV"`
-	} `mapstructure:"LOGGER"`
-	GRYDContract Contract `mapstructure:"GRYD_CONTRACT"`
-	ChainConfig  Crypto   `mapstructure:"CRYPTO"`
+	Logger       Logger     `mapstructure:"LOGGER"`
+	GRYDContract Contract   `mapstructure:"GRYD_CONTRACT"`
+	ChainConfig  Crypto     `mapstructure:"CRYPTO"`
+	Auth         Auth       `mapstructure:"AUTH"`
+	GRYDAccess   GRYDAccess `mapstructure:"GRYD_ACCESS"`
+	RateLimit    RateLimit  `mapstructure:"RATE_LIMIT"`
+	Quota        Quota      `mapstructure:"QUOTA"`
+	Indexer      Indexer    `mapstructure:"INDEXER"`
+	Reorg        Reorg      `mapstructure:"REORG"`
+	TxMonitor    TxMonitor  `mapstructure:"TX_MONITOR"`
+	Backend      Backend    `mapstructure:"BACKEND"`
+	Sync         Sync       `mapstructure:"SYNC"`
+	Shutdown     Shutdown   `mapstructure:"SHUTDOWN"`
+	Tracing      Tracing    `mapstructure:"TRACING"`
 }
 
+// Logger configures the log level and where logs are written, Output is "stdout" or "file". A file is rotated once
+// it reaches MaxSize megabytes, MaxBackups rotated files are kept for at most MaxAge days, 0 keeps them all.
+// LogEnv "local" is kept for older configurations and writes to File when no Output is set.
+type Logger struct {
+	LogLevel   string `mapstructure:"LOG_LEVEL"`
+	LogEnv     string `mapstructure:"LOG_ENV"`
+	Output     string `mapstructure:"OUTPUT"`
+	File       string `mapstructure:"FILE"`
+	MaxSize    int    `mapstructure:"MAX_SIZE"`
+	MaxBackups int    `mapstructure:"MAX_BACKUPS"`
+	MaxAge     int    `mapstructure:"MAX_AGE"`
+	Compress   bool   `mapstructure:"COMPRESS"`
+}
+
+// Crypto configures the chain endpoints and the node key, Endpoints are used next to the primary Endpoint.
+// The key is loaded from the V3 keystore file when KeystorePath is set and its password read from PasswordFile
+// or else from the PasswordEnv environment variable
 type Crypto struct {
-	PrivateKey string `mapstructure:"PRIVATE_KEY"`
-	Endpoint   string `mapstructure:"ENDPOINT"`
+	PrivateKey   string   `mapstructure:"PRIVATE_KEY"`
+	KeystorePath string   `mapstructure:"KEYSTORE_PATH"`
+	PasswordFile string   `mapstructure:"PASSWORD_FILE"`
+	PasswordEnv  string   `mapstructure:"PASSWORD_ENV"`
+	Endpoint     string   `mapstructure:"ENDPOINT"`
+	Endpoints    []string `mapstructure:"ENDPOINTS"`
+}
+
+// Auth configures the sign-in with ethereum messages, the scopes granted on login and the lifetime of nonces and tokens
+type Auth struct {
+	Domain      string        `mapstructure:"DOMAIN"`
+	URI         string        `mapstructure:"URI"`
+	Statement   string        `mapstructure:"STATEMENT"`
+	ChainID     int64         `mapstructure:"CHAIN_ID"`
+	Scopes      []string      `mapstructure:"SCOPES"`
+	NonceTTL    time.Duration `mapstructure:"NONCE_TTL"`
+	SessionTTL  time.Duration `mapstructure:"SESSION_TTL"`
+	RefreshTTL  time.Duration `mapstructure:"REFRESH_TTL"`
+	MaxNonces   int           `mapstructure:"MAX_NONCES"`
+	MaxSessions int           `mapstructure:"MAX_SESSIONS"`
+}
+
+// GRYDAccess bounds the concurrent on-chain operations, Capacity is the total weight shared by the
+// operations and Weight the weight taken by a single one
+type GRYDAccess struct {
+	Capacity     int64         `mapstructure:"CAPACITY"`
+	Weight       int64         `mapstructure:"WEIGHT"`
+	QueueTimeout time.Duration `mapstructure:"QUEUE_TIMEOUT"`
+	MaxPerWallet int           `mapstructure:"MAX_PER_WALLET"`
+}
+
+// RateLimit configures the token buckets of wallets and client addresses, Rate is in requests per second
+type RateLimit struct {
+	Rate  float64 `mapstructure:"RATE"`
+	Burst int     `mapstructure:"BURST"`
+}
+
+// Quota bounds the rows and bytes stored by a single wallet, 0 is unlimited
+type Quota struct {
+	MaxRows  int64 `mapstructure:"MAX_ROWS"`
+	MaxBytes int64 `mapstructure:"MAX_BYTES"`
+}
+
+// Indexer configures the ingestion of InsertDataSuccess events, when enabled storage requests are verified
+// against the indexed events instead of live receipts
+type Indexer struct {
+	Enabled       bool          `mapstructure:"ENABLED"`
+	StartBlock    uint64        `mapstructure:"START_BLOCK"`
+	Confirmations uint64        `mapstructure:"CONFIRMATIONS"`
+	PollInterval  time.Duration `mapstructure:"POLL_INTERVAL"`
+	BatchSize     uint64        `mapstructure:"BATCH_SIZE"`
+}
+
+// Reorg configures the confirmations required before a tx is accepted and the background re-check of the
+// datasets paid by txs that are not final yet
+type Reorg struct {
+	Confirmations   uint64        `mapstructure:"CONFIRMATIONS"`
+	RecheckInterval time.Duration `mapstructure:"RECHECK_INTERVAL"`
+	Grace           time.Duration `mapstructure:"GRACE"`
+	Rollback        bool          `mapstructure:"ROLLBACK"`
+}
+
+// TxMonitor configures the monitor of the transactions sent by the node, transactions not mined after StuckAfter
+// are sent again with their fees boosted by ResendBoost percent
+type TxMonitor struct {
+	PollInterval time.Duration `mapstructure:"POLL_INTERVAL"`
+	StuckAfter   time.Duration `mapstructure:"STUCK_AFTER"`
+	ResendBoost  int           `mapstructure:"RESEND_BOOST"`
+}
+
+// Backend configures the health checks of the chain endpoints, an endpoint lagging more than Sync.MaxDelay behind
+// is only used when no other one is left. Quorum is the number of endpoints that must agree on a receipt.
+// Every call is bounded by Timeout and transient errors are retried MaxRetries times with a backoff doubling from
+// InitialBackoff up to MaxBackoff, a receipt that is not found yet is asked again during ReceiptGrace.
+type Backend struct {
+	HealthCheckInterval time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	Quorum              int           `mapstructure:"QUORUM"`
+	Timeout             time.Duration `mapstructure:"TIMEOUT"`
+	MaxRetries          int           `mapstructure:"MAX_RETRIES"`
+	InitialBackoff      time.Duration `mapstructure:"INITIAL_BACKOFF"`
+	MaxBackoff          time.Duration `mapstructure:"MAX_BACKOFF"`
+	ReceiptGrace        time.Duration `mapstructure:"RECEIPT_GRACE"`
+}
+
+// Sync configures when the chain backend is considered synced, events are not verified while its last block is
+// older than MaxDelay, endpoints lagging more are avoided and the node waits for it at startup when WaitAtStartup
+// is set
+type Sync struct {
+	MaxDelay      time.Duration `mapstructure:"MAX_DELAY"`
+	WaitAtStartup bool          `mapstructure:"WAIT_AT_STARTUP"`
+}
+
+// Shutdown bounds the time the node waits for in-flight requests before closing its stores and chain backend
+type Shutdown struct {
+	Timeout time.Duration `mapstructure:"TIMEOUT"`
+}
+
+// Tracing configures the export of spans, Exporter is "otlp" to send them over http to Endpoint, "stdout" to print
+// them or empty to disable tracing. SampleRatio is the fraction of new traces sampled, 0 samples every trace.
+type Tracing struct {
+	Exporter    string  `mapstructure:"EXPORTER"`
+	Endpoint    string  `mapstructure:"ENDPOINT"`
+	Insecure    bool    `mapstructure:"INSECURE"`
+	ServiceName string  `mapstructure:"SERVICE_NAME"`
+	SampleRatio float64 `mapstructure:"SAMPLE_RATIO"`
 }
 
 type Contract struct {
@@ -51,6 +185,9 @@ func Init() (*Config, error) {
 	}
 	config := Config{}
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode config: %w", err)
+	}
 
 	return &config, nil
 }
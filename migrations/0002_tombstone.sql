@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tombstone (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    wallet TEXT NOT NULL,
+    txHash TEXT NOT NULL,
+    datasetKey TEXT NOT NULL,
+    recordId TEXT NOT NULL DEFAULT '',
+    queryType TEXT NOT NULL,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+---- create above / drop below ----
+DROP TABLE IF EXISTS tombstone;
@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS storage_wallet_idx ON storage (lower(wallet), createdAt DESC);
+CREATE INDEX IF NOT EXISTS storage_dataset_key_idx ON storage (datasetKey);
+
+---- create above / drop below ----
+DROP INDEX IF EXISTS storage_dataset_key_idx;
+DROP INDEX IF EXISTS storage_wallet_idx;
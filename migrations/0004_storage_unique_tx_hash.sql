@@ -0,0 +1,29 @@
+-- rows that replayed a tx hash before the index existed are archived, the earliest row of every tx hash is kept
+CREATE TABLE IF NOT EXISTS storage_duplicate (
+    id UUID PRIMARY KEY,
+    wallet TEXT NOT NULL,
+    txHash TEXT NOT NULL,
+    createdAt TIMESTAMP NOT NULL,
+    datasetKey TEXT NOT NULL,
+    archivedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+WITH duplicate AS (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY lower(txHash) ORDER BY createdAt, id) AS rank
+        FROM storage
+    ) ranked
+    WHERE rank > 1
+), archived AS (
+    DELETE FROM storage WHERE id IN (SELECT id FROM duplicate)
+    RETURNING id, wallet, txHash, createdAt, datasetKey
+)
+INSERT INTO storage_duplicate (id, wallet, txHash, createdAt, datasetKey)
+SELECT id, wallet, txHash, createdAt, datasetKey FROM archived
+ON CONFLICT (id) DO NOTHING;
+
+CREATE UNIQUE INDEX IF NOT EXISTS storage_tx_hash_key ON storage (lower(txHash));
+
+---- create above / drop below ----
+DROP INDEX IF EXISTS storage_tx_hash_key;
+DROP TABLE IF EXISTS storage_duplicate;
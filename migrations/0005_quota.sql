@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS quota (
+    wallet TEXT PRIMARY KEY,
+    rowsStored BIGINT NOT NULL DEFAULT 0,
+    bytesStored BIGINT NOT NULL DEFAULT 0,
+    updatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+---- create above / drop below ----
+DROP TABLE IF EXISTS quota;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS event (
+    txHash TEXT NOT NULL,
+    logIndex INTEGER NOT NULL,
+    blockNumber BIGINT NOT NULL,
+    blockHash TEXT NOT NULL,
+    wallet TEXT NOT NULL,
+    queryType TEXT NOT NULL,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (txHash, logIndex)
+    );
+
+CREATE INDEX IF NOT EXISTS event_lower_tx_hash_idx ON event (lower(txHash));
+
+CREATE TABLE IF NOT EXISTS indexer (
+    contract TEXT PRIMARY KEY,
+    lastBlock BIGINT NOT NULL,
+    updatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+---- create above / drop below ----
+DROP TABLE IF EXISTS indexer;
+DROP TABLE IF EXISTS event;
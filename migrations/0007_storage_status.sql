@@ -0,0 +1,9 @@
+-- datasets stored before the reorg watcher are backfilled as final, only new inserts start out pending
+ALTER TABLE storage ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'final';
+ALTER TABLE storage ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS storage_status_idx ON storage (status, createdAt);
+
+---- create above / drop below ----
+DROP INDEX IF EXISTS storage_status_idx;
+ALTER TABLE storage DROP COLUMN IF EXISTS status;
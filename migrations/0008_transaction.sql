@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS sent_transaction (
+    txHash TEXT PRIMARY KEY,
+    toAddress TEXT,
+    data BYTEA NOT NULL,
+    gasPrice TEXT NOT NULL,
+    gasLimit BIGINT NOT NULL,
+    gasTipBoost INTEGER NOT NULL,
+    gasTipCap TEXT NOT NULL,
+    gasFeeCap TEXT NOT NULL,
+    value TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    created BIGINT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    fee TEXT,
+    updatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS sent_transaction_status_idx ON sent_transaction (status, nonce);
+
+CREATE TABLE IF NOT EXISTS sender_nonce (
+    sender TEXT PRIMARY KEY,
+    nonce BIGINT NOT NULL,
+    updatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+---- create above / drop below ----
+DROP TABLE IF EXISTS sender_nonce;
+DROP TABLE IF EXISTS sent_transaction;
@@ -0,0 +1,4 @@
+CREATE UNIQUE INDEX IF NOT EXISTS tombstone_tx_hash_key ON tombstone (lower(txHash));
+
+---- create above / drop below ----
+DROP INDEX IF EXISTS tombstone_tx_hash_key;
@@ -0,0 +1,6 @@
+-- cancellations sent before the column existed are recognised by the description CancelTransaction gives them
+ALTER TABLE sent_transaction ADD COLUMN IF NOT EXISTS cancellation BOOLEAN NOT NULL DEFAULT false;
+UPDATE sent_transaction SET cancellation = true WHERE description LIKE 'cancel 0x%';
+
+---- create above / drop below ----
+ALTER TABLE sent_transaction DROP COLUMN IF EXISTS cancellation;
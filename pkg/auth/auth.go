@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidMessage   = errors.New("invalid sign-in message")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrUnknownNonce     = errors.New("unknown or expired nonce")
+	ErrMessageExpired   = errors.New("sign-in message expired")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrMissingSecret    = errors.New("missing jwt secret")
+	ErrTooManyNonces    = errors.New("too many outstanding nonces")
+	ErrTooManySessions  = errors.New("too many active sessions")
+)
+
+const (
+	defaultNonceTTL    = 10 * time.Minute
+	defaultSessionTTL  = 15 * time.Minute
+	defaultRefreshTTL  = 7 * 24 * time.Hour
+	defaultMaxNonces   = 10000
+	defaultMaxSessions = 100000
+)
+
+type Service interface {
+	// Nonce issues a single use nonce for the address and the sign-in message the wallet has to sign with it.
+	Nonce(address common.Address) (*Challenge, error)
+	// Login verifies the EIP-191 signature of a sign-in message and issues access and refresh tokens for the signer.
+	Login(message string, signature []byte) (*Session, error)
+	// Refresh issues new access and refresh tokens carrying the claims of a valid refresh token, the refresh token
+	// can only be used once.
+	Refresh(refreshToken string) (*Session, error)
+	// Authenticate verifies an access token and returns its claims.
+	Authenticate(token string) (*Claims, error)
+}
+
+// Challenge holds the nonce issued to a wallet and the message it has to sign
+type Challenge struct {
+	Nonce   string `json:"nonce"`
+	Message string `json:"message"`
+}
+
+// Session holds the tokens of a signed-in wallet, ExpiresAt is the expiry of the access token
+type Session struct {
+	AccessToken  string         `json:"accessToken"`
+	RefreshToken string         `json:"refreshToken"`
+	Address      common.Address `json:"address"`
+	Scopes       []string       `json:"scopes"`
+	ExpiresAt    time.Time      `json:"expiresAt"`
+}
+
+// Options configures the sign-in messages and the tokens issued by the service, SessionTTL is the lifetime of access tokens,
+// MaxNonces bounds the nonces that are issued but neither used nor expired and MaxSessions does the same for refresh tokens
+type Options struct {
+	Domain      string
+	URI         string
+	Statement   string
+	ChainID     int64
+	Secret      []byte
+	Scopes      []string
+	NonceTTL    time.Duration
+	SessionTTL  time.Duration
+	RefreshTTL  time.Duration
+	MaxNonces   int
+	MaxSessions int
+}
+
+type nonce struct {
+	address   common.Address
+	expiresAt time.Time
+}
+
+type authService struct {
+	lock    sync.Mutex
+	options Options
+	nonces  map[string]nonce
+	// refreshTokens maps the id of every refresh token that is issued but neither used nor expired to its expiry
+	refreshTokens map[string]time.Time
+	now           func() time.Time
+}
+
+func New(options Options) (Service, error) {
+	if len(options.Secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+	if len(options.Scopes) == 0 {
+		options.Scopes = DefaultScopes
+	}
+	if options.NonceTTL == 0 {
+		options.NonceTTL = defaultNonceTTL
+	}
+	if options.SessionTTL == 0 {
+		options.SessionTTL = defaultSessionTTL
+	}
+	if options.RefreshTTL == 0 {
+		options.RefreshTTL = defaultRefreshTTL
+	}
+	if options.MaxNonces <= 0 {
+		options.MaxNonces = defaultMaxNonces
+	}
+	if options.MaxSessions <= 0 {
+		options.MaxSessions = defaultMaxSessions
+	}
+
+	return &authService{
+		options:       options,
+		nonces:        make(map[string]nonce),
+		refreshTokens: make(map[string]time.Time),
+		now:           time.Now,
+	}, nil
+}
+
+func (s *authService) Nonce(address common.Address) (*Challenge, error) {
+	value, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	now := s.now().UTC()
+	message := Message{
+		Domain:         s.options.Domain,
+		Address:        address,
+		Statement:      s.options.Statement,
+		URI:            s.options.URI,
+		Version:        "1",
+		ChainID:        s.options.ChainID,
+		Nonce:          value,
+		IssuedAt:       now,
+		ExpirationTime: now.Add(s.options.NonceTTL),
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.purgeExpired(now)
+	if len(s.nonces) >= s.options.MaxNonces {
+		return nil, ErrTooManyNonces
+	}
+	s.nonces[value] = nonce{address: address, expiresAt: message.ExpirationTime}
+
+	return &Challenge{Nonce: value, Message: message.String()}, nil
+}
+
+func (s *authService) Login(message string, signature []byte) (*Session, error) {
+	m, err := ParseMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Domain != s.options.Domain || m.ChainID != s.options.ChainID {
+		return nil, fmt.Errorf("%w: unexpected domain or chain id", ErrInvalidMessage)
+	}
+
+	now := s.now().UTC()
+	if !m.ExpirationTime.IsZero() && now.After(m.ExpirationTime) {
+		return nil, ErrMessageExpired
+	}
+
+	signer, err := RecoverAddress([]byte(message), signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if signer != m.Address {
+		return nil, ErrInvalidSignature
+	}
+
+	s.lock.Lock()
+	issued, ok := s.nonces[m.Nonce]
+	if ok && issued.address == m.Address {
+		delete(s.nonces, m.Nonce)
+	}
+	s.lock.Unlock()
+
+	if !ok || issued.address != m.Address || now.After(issued.expiresAt) {
+		return nil, ErrUnknownNonce
+	}
+
+	return s.issueSession(m.Address, s.options.Scopes, now)
+}
+
+func (s *authService) Refresh(refreshToken string) (*Session, error) {
+	now := s.now().UTC()
+
+	claims, err := parseToken(s.options.Secret, refreshToken, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != TokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
+
+	// the refresh token is rotated, a second use of the same token is rejected
+	s.lock.Lock()
+	_, ok := s.refreshTokens[claims.ID]
+	delete(s.refreshTokens, claims.ID)
+	s.lock.Unlock()
+
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return s.issueSession(claims.Address(), claims.Scopes, now)
+}
+
+func (s *authService) Authenticate(token string) (*Claims, error) {
+	claims, err := parseToken(s.options.Secret, token, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != TokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (s *authService) issueSession(address common.Address, scopes []string, now time.Time) (*Session, error) {
+	session := &Session{
+		Address:   address,
+		Scopes:    scopes,
+		ExpiresAt: now.Add(s.options.SessionTTL),
+	}
+
+	var err error
+	session.AccessToken, err = signToken(s.options.Secret, &Claims{
+		Issuer:    s.options.Domain,
+		Subject:   address.Hex(),
+		Type:      TokenTypeAccess,
+		Scopes:    scopes,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign access token: %w", err)
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate refresh token id: %w", err)
+	}
+
+	refreshExpiresAt := now.Add(s.options.RefreshTTL)
+	session.RefreshToken, err = signToken(s.options.Secret, &Claims{
+		ID:        id,
+		Issuer:    s.options.Domain,
+		Subject:   address.Hex(),
+		Type:      TokenTypeRefresh,
+		Scopes:    scopes,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: refreshExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign refresh token: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.purgeExpired(now)
+	if len(s.refreshTokens) >= s.options.MaxSessions {
+		return nil, ErrTooManySessions
+	}
+	s.refreshTokens[id] = refreshExpiresAt
+
+	return session, nil
+}
+
+// purgeExpired drops expired nonces and refresh tokens, the lock must be held by the caller
+func (s *authService) purgeExpired(now time.Time) {
+	for key, issued := range s.nonces {
+		if now.After(issued.expiresAt) {
+			delete(s.nonces, key)
+		}
+	}
+
+	for id, expiresAt := range s.refreshTokens {
+		if !now.Before(expiresAt) {
+			delete(s.refreshTokens, id)
+		}
+	}
+}
+
+// RecoverAddress returns the address that produced the EIP-191 personal_sign signature of data
+func RecoverAddress(data, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	// wallets return the recovery id as 27/28 while go-ethereum expects 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash(data), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+type contextKey struct{}
+
+// WithClaims returns a copy of the context carrying the claims of the authenticated request
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated request
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// AddressFromContext returns the wallet address of the authenticated request
+func AddressFromContext(ctx context.Context) (common.Address, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return common.Address{}, false
+	}
+
+	return claims.Address(), true
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
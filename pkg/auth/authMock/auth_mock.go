@@ -0,0 +1,80 @@
+package authMock
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gryd-database/platform-poc/pkg/auth"
+	"github.com/pkg/errors"
+)
+
+type authMock struct {
+	nonce        func(address common.Address) (*auth.Challenge, error)
+	login        func(message string, signature []byte) (*auth.Session, error)
+	refresh      func(refreshToken string) (*auth.Session, error)
+	authenticate func(token string) (*auth.Claims, error)
+}
+
+func (a *authMock) Nonce(address common.Address) (*auth.Challenge, error) {
+	if a.nonce != nil {
+		return a.nonce(address)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (a *authMock) Login(message string, signature []byte) (*auth.Session, error) {
+	if a.login != nil {
+		return a.login(message, signature)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (a *authMock) Refresh(refreshToken string) (*auth.Session, error) {
+	if a.refresh != nil {
+		return a.refresh(refreshToken)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (a *authMock) Authenticate(token string) (*auth.Claims, error) {
+	if a.authenticate != nil {
+		return a.authenticate(token)
+	}
+	return nil, auth.ErrInvalidToken
+}
+
+// Option is an option passed to New
+type Option func(mock *authMock)
+
+// New creates a new mock
+func New(opts ...Option) auth.Service {
+	bs := &authMock{}
+
+	for _, o := range opts {
+		o(bs)
+	}
+
+	return bs
+}
+
+func WithNonce(f func(address common.Address) (*auth.Challenge, error)) Option {
+	return func(mock *authMock) {
+		mock.nonce = f
+	}
+}
+
+func WithLogin(f func(message string, signature []byte) (*auth.Session, error)) Option {
+	return func(mock *authMock) {
+		mock.login = f
+	}
+}
+
+func WithRefresh(f func(refreshToken string) (*auth.Session, error)) Option {
+	return func(mock *authMock) {
+		mock.refresh = f
+	}
+}
+
+func WithAuthenticate(f func(token string) (*auth.Claims, error)) Option {
+	return func(mock *authMock) {
+		mock.authenticate = f
+	}
+}
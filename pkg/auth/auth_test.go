@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	options := Options{Domain: "gryd.test", URI: "https://gryd.test", Statement: "Sign in to GRYD", ChainID: 5, Secret: []byte("secret")}
+
+	newService := func(t *testing.T) *authService {
+		t.Helper()
+
+		service, err := New(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return service.(*authService)
+	}
+
+	sign := func(t *testing.T, key *ecdsa.PrivateKey, message string) []byte {
+		t.Helper()
+
+		signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature[crypto.RecoveryIDOffset] += 27
+
+		return signature
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := crypto.PubkeyToAddress(key.PublicKey)
+
+		service := newService(t)
+
+		challenge, err := service.Nonce(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		session, err := service.Login(challenge.Message, sign(t, key, challenge.Message))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims, err := service.Authenticate(session.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims.Address() != address || !claims.HasScope(ScopeStorageWrite) {
+			t.Fatalf("unexpected claims %+v", claims)
+		}
+
+		_, err = service.Authenticate(session.RefreshToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected refresh token to be rejected as access token, got %v", err)
+		}
+
+		_, err = service.Login(challenge.Message, sign(t, key, challenge.Message))
+		if !errors.Is(err, ErrUnknownNonce) {
+			t.Fatalf("expected nonce to be single use, got %v", err)
+		}
+	})
+
+	t.Run("signed by other wallet", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		service := newService(t)
+
+		challenge, err := service.Nonce(crypto.PubkeyToAddress(key.PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = service.Login(challenge.Message, sign(t, otherKey, challenge.Message))
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected invalid signature error, got %v", err)
+		}
+	})
+
+	t.Run("expired message", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		service := newService(t)
+
+		challenge, err := service.Nonce(crypto.PubkeyToAddress(key.PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		service.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		_, err = service.Login(challenge.Message, sign(t, key, challenge.Message))
+		if !errors.Is(err, ErrMessageExpired) {
+			t.Fatalf("expected expired message error, got %v", err)
+		}
+	})
+
+	t.Run("too many nonces", func(t *testing.T) {
+		t.Parallel()
+
+		capped := options
+		capped.MaxNonces = 2
+
+		service, err := New(capped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := common.HexToAddress("0xD07708ad91fbE34329507E2adABfb31534dD3efd")
+
+		for i := 0; i < capped.MaxNonces; i++ {
+			if _, err := service.Nonce(address); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err = service.Nonce(address)
+		if !errors.Is(err, ErrTooManyNonces) {
+			t.Fatalf("expected too many nonces error, got %v", err)
+		}
+
+		// expired nonces no longer count against the cap
+		service.(*authService).now = func() time.Time { return time.Now().Add(time.Hour) }
+
+		if _, err := service.Nonce(address); err != nil {
+			t.Fatalf("expected a nonce once the others expired, got %v", err)
+		}
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := New(Options{Domain: "gryd.test"})
+		if !errors.Is(err, ErrMissingSecret) {
+			t.Fatalf("expected missing secret error, got %v", err)
+		}
+	})
+}
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	address := common.HexToAddress("0xD07708ad91fbE34329507E2adABfb31534dD3efd")
+
+	newService := func(t *testing.T, secret string) *authService {
+		t.Helper()
+
+		service, err := New(Options{Domain: "gryd.test", Secret: []byte(secret)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return service.(*authService)
+	}
+
+	t.Run("refresh", func(t *testing.T) {
+		t.Parallel()
+
+		service := newService(t, "secret")
+
+		session, err := service.issueSession(address, []string{ScopeStorageRead}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		refreshed, err := service.Refresh(session.RefreshToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims, err := service.Authenticate(refreshed.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims.Address() != address || claims.HasScope(ScopeStorageWrite) {
+			t.Fatalf("unexpected claims %+v", claims)
+		}
+
+		_, err = service.Refresh(session.AccessToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected access token to be rejected as refresh token, got %v", err)
+		}
+
+		_, err = service.Refresh(session.RefreshToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected used refresh token to be rejected, got %v", err)
+		}
+
+		_, err = service.Refresh(refreshed.RefreshToken)
+		if err != nil {
+			t.Fatalf("expected rotated refresh token to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		service := newService(t, "secret")
+
+		session, err := service.issueSession(address, DefaultScopes, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = service.Authenticate(session.AccessToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected expired token to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		t.Parallel()
+
+		session, err := newService(t, "secret").issueSession(address, DefaultScopes, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = newService(t, "other").Authenticate(session.AccessToken)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected token signed with other secret to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newService(t, "secret").Authenticate("token")
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected invalid token error, got %v", err)
+		}
+	})
+
+	t.Run("too many sessions", func(t *testing.T) {
+		t.Parallel()
+
+		service, err := New(Options{Secret: []byte("secret"), MaxSessions: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = service.(*authService).issueSession(address, DefaultScopes, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = service.(*authService).issueSession(address, DefaultScopes, time.Now())
+		if !errors.Is(err, ErrTooManySessions) {
+			t.Fatalf("expected too many sessions error, got %v", err)
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		t.Parallel()
+
+		service := newService(t, "secret")
+
+		session, err := service.issueSession(address, DefaultScopes, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload := strings.Split(session.AccessToken, ".")[1]
+
+		tests := []struct {
+			header string
+			valid  bool
+		}{
+			{header: `{"typ":"JWT","alg":"HS256"}`, valid: true},
+			{header: `{"alg":"HS256"}`, valid: true},
+			{header: `{"alg":"none","typ":"JWT"}`, valid: false},
+			{header: `{"alg":"HS512","typ":"JWT"}`, valid: false},
+			{header: `{"alg":"HS256","typ":"JWE"}`, valid: false},
+		}
+
+		for _, tc := range tests {
+			unsigned := base64.RawURLEncoding.EncodeToString([]byte(tc.header)) + "." + payload
+			token := unsigned + "." + base64.RawURLEncoding.EncodeToString(tokenSignature([]byte("secret"), unsigned))
+
+			_, err := service.Authenticate(token)
+			if tc.valid != (err == nil) {
+				t.Fatalf("unexpected result for header %s: %v", tc.header, err)
+			}
+		}
+	})
+}
+
+func TestParseMessage(t *testing.T) {
+	t.Parallel()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := Message{
+		Domain:         "gryd.test",
+		Address:        crypto.PubkeyToAddress(key.PublicKey),
+		Statement:      "Sign in to GRYD",
+		URI:            "https://gryd.test",
+		Version:        "1",
+		ChainID:        5,
+		Nonce:          "abcdef0123456789",
+		IssuedAt:       time.Date(2023, 7, 10, 6, 47, 17, 0, time.UTC),
+		ExpirationTime: time.Date(2023, 7, 10, 6, 57, 17, 0, time.UTC),
+	}
+
+	parsed, err := ParseMessage(message.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *parsed != message {
+		t.Fatalf("expected %+v, got %+v", message, *parsed)
+	}
+
+	_, err = ParseMessage("hello world")
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("expected invalid message error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// scopes granted to a signed-in wallet
+const (
+	ScopeStorageRead  = "storage:read"
+	ScopeStorageWrite = "storage:write"
+	ScopeBalanceRead  = "balance:read"
+)
+
+// DefaultScopes are granted on login when no scopes are configured
+var DefaultScopes = []string{ScopeStorageRead, ScopeStorageWrite, ScopeBalanceRead}
+
+const jwtAlgorithm = "HS256"
+
+// jwtHeader is the header of the issued tokens
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + jwtAlgorithm + `","typ":"JWT"}`))
+
+// header holds the fields of a JOSE header checked on parse, tokens signed with any other algorithm are rejected
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+// Claims are the claims of the HS256 JWTs issued by the service, Subject holds the wallet address and ID identifies
+// a refresh token so it can be used only once
+type Claims struct {
+	ID        string   `json:"jti,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub"`
+	Type      string   `json:"typ"`
+	Scopes    []string `json:"scopes"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+func (c *Claims) Address() common.Address {
+	return common.HexToAddress(c.Subject)
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func signToken(secret []byte, claims *Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal claims: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(secret, unsigned)), nil
+}
+
+func parseToken(secret []byte, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if h.Algorithm != jwtAlgorithm || (len(h.Type) > 0 && !strings.EqualFold(h.Type, "JWT")) {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, tokenSignature(secret, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !common.IsHexAddress(claims.Subject) || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+func tokenSignature(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const messageHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// Message is an EIP-4361 sign-in with ethereum message
+type Message struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+}
+
+// String formats the message the way it has to be signed by the wallet
+func (m *Message) String() string {
+	var b strings.Builder
+
+	b.WriteString(m.Domain + messageHeaderSuffix + "\n")
+	b.WriteString(m.Address.Hex() + "\n")
+	if len(m.Statement) > 0 {
+		b.WriteString("\n" + m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatInt(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt.UTC().Format(time.RFC3339))
+	if !m.ExpirationTime.IsZero() {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+
+	return b.String()
+}
+
+// ParseMessage parses an EIP-4361 message, fields not used by the node (resources, request id, not before) are ignored
+func ParseMessage(message string) (*Message, error) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], messageHeaderSuffix) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidMessage)
+	}
+
+	if !common.IsHexAddress(lines[1]) {
+		return nil, fmt.Errorf("%w: invalid address", ErrInvalidMessage)
+	}
+
+	m := &Message{
+		Domain:  strings.TrimSuffix(lines[0], messageHeaderSuffix),
+		Address: common.HexToAddress(lines[1]),
+	}
+
+	var statement []string
+	for _, line := range lines[2:] {
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			statement = append(statement, line)
+			continue
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			m.URI = value
+		case "Version":
+			m.Version = value
+		case "Chain ID":
+			m.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			m.Nonce = value
+		case "Issued At":
+			m.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			m.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		default:
+			statement = append(statement, line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidMessage, key, err)
+		}
+	}
+
+	m.Statement = strings.TrimSpace(strings.Join(statement, "\n"))
+
+	if len(m.Nonce) == 0 || len(m.URI) == 0 || m.Version != "1" {
+		return nil, fmt.Errorf("%w: missing required fields", ErrInvalidMessage)
+	}
+
+	return m, nil
+}
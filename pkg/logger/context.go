@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// requestEntry is the entry of a request, fields are added in place so the middlewares that wrap a handler log with
+// the fields the handler added
+type requestEntry struct {
+	lock  sync.Mutex
+	entry *logrus.Entry
+}
+
+// NewContext returns a copy of the context carrying the entry requests handled with the context log with
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestEntry{entry: entry})
+}
+
+// FromContext returns the entry carried by the context or a new entry of fallback when there is none
+func FromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	re, ok := ctx.Value(contextKey{}).(*requestEntry)
+	if !ok {
+		return logrus.NewEntry(fallback)
+	}
+
+	re.lock.Lock()
+	defer re.lock.Unlock()
+
+	return re.entry
+}
+
+// AddFields adds the fields to the entry carried by the context, it does nothing when there is none
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	re, ok := ctx.Value(contextKey{}).(*requestEntry)
+	if !ok {
+		return
+	}
+
+	re.lock.Lock()
+	defer re.lock.Unlock()
+
+	re.entry = re.entry.WithFields(fields)
+}
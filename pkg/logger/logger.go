@@ -1,27 +1,31 @@
 package logger
 
 import (
+	"fmt"
 	"github.com/gryd-database/platform-poc/configuration"
 	"os"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var filepath = "./pkg/logger/logrus.log"
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
 
-var logger_instance = logrus.New()
+	defaultFile = "logs/gryd.log"
+)
 
-type loggerService struct {
-	logger *logrus.Logger
-}
+var logger_instance = logrus.New()
 
 func Init(configService *configuration.Config) (*logrus.Logger, error) {
+	args := configService.Logger
 
 	// setting the format of the logs to be a JSON one
-	logger_instance.SetFormatter(&logrus.JSONFormatter{PrettyPrint: true})
+	logger_instance.SetFormatter(&logrus.JSONFormatter{})
 
 	// getting the log level set in the configuration file
-	logLevel, err := logrus.ParseLevel(configService.Logger.LogLevel)
+	logLevel, err := logrus.ParseLevel(args.LogLevel)
 	// If the log level in conf file can't be parsed, log level should be the default info level
 	if err != nil {
 		logLevel = logrus.InfoLevel
@@ -29,17 +33,31 @@ func Init(configService *configuration.Config) (*logrus.Logger, error) {
 	// setting the log level
 	logger_instance.SetLevel(logLevel)
 
-	if configService.Logger.LogEnv == "local" { // If we want to throw logs into a local file
+	output := args.Output
+	if output == "" && args.LogEnv == "local" {
+		output = OutputFile
+	}
 
+	switch output {
+	case "", OutputStdout:
 		logger_instance.SetOutput(os.Stdout)
-		// setting it to a file writer
-		file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-
-		if err == nil {
-			logger_instance.Out = file
-		} else {
-			logger_instance.Info("Failed to log to file, using default stderr")
+	case OutputFile:
+		filename := args.File
+		if filename == "" {
+			filename = defaultFile
 		}
+
+		// the file and its directory are created on the first write, it is rotated once it reaches MaxSize
+		logger_instance.SetOutput(&lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    args.MaxSize,
+			MaxBackups: args.MaxBackups,
+			MaxAge:     args.MaxAge,
+			Compress:   args.Compress,
+		})
+	default:
+		return nil, fmt.Errorf("unknown log output %q", output)
 	}
+
 	return logger_instance, nil
 }
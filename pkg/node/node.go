@@ -2,53 +2,199 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gryd-database/platform-poc/configuration"
 	"github.com/gryd-database/platform-poc/pkg/signer"
 	"github.com/gryd-database/platform-poc/pkg/transaction"
 	"github.com/sirupsen/logrus"
+	"math/big"
+	"os"
+	"strings"
+	"time"
 )
 
+// ChainOptions configures the transaction monitor, the health checks of the endpoints and the retries of the calls.
+// WaitSynced blocks the startup until the last block of the backend is at most SyncMaxDelay old.
+type ChainOptions struct {
+	Monitor      transaction.MonitorOptions
+	Backend      transaction.MultiBackendOptions
+	Retry        transaction.RetryOptions
+	WaitSynced   bool
+	SyncMaxDelay time.Duration
+}
+
+// InitChain connects to every configured endpoint and creates the transaction service on top of them, endpoints
+// that cannot be reached are skipped and all the others must be on the same chain
 func InitChain(
 	ctx context.Context,
 	logger *logrus.Logger,
-	endpoint string,
-	hexKey string) (*rpc.Client, common.Address, *transaction.Service, error) {
+	endpoints []string,
+	signer signer.Signer,
+	store transaction.Store,
+	options ChainOptions) (*rpc.Client, common.Address, *transaction.Service, error) {
+
+	var (
+		rpcClient *rpc.Client
+		chainID   *big.Int
+		backends  []transaction.Endpoint
+	)
+
+	if options.Backend.Quorum > len(endpoints) {
+		return nil, common.Address{}, nil, fmt.Errorf("%w: quorum %d with %d configured endpoints", transaction.ErrQuorumTooLarge, options.Backend.Quorum, len(endpoints))
+	}
+
+	for _, endpoint := range endpoints {
+		client, endpointChainID, err := dialEndpoint(ctx, logger, endpoint)
+		if err != nil {
+			logger.Error("skipping endpoint ", endpoint, ": ", err)
+			continue
+		}
+
+		if chainID != nil && chainID.Cmp(endpointChainID) != 0 {
+			client.Close()
+			return nil, common.Address{}, nil, fmt.Errorf("endpoint %s is on chain %d instead of %d", endpoint, endpointChainID, chainID)
+		}
+
+		if rpcClient == nil {
+			rpcClient = client
+			chainID = endpointChainID
+		}
+
+		backends = append(backends, transaction.Endpoint{Name: endpoint, Backend: ethclient.NewClient(client)})
+	}
+
+	if len(backends) == 0 {
+		return nil, common.Address{}, nil, errors.New("could not connect to backend, requires a working blockchain node, please check your endpoints")
+	}
+
+	// endpoints that could not be reached may leave fewer endpoints than the quorum needs
+	multiBackend, err := transaction.NewMultiBackend(logger, backends, options.Backend)
+	if err != nil {
+		for _, e := range backends {
+			e.Backend.Close()
+		}
+		return nil, common.Address{}, nil, err
+	}
+
+	backend := transaction.NewBackend(multiBackend, options.Retry)
+
+	if options.SyncMaxDelay <= 0 {
+		options.SyncMaxDelay = transaction.DefaultMaxSyncDelay
+	}
+
+	if options.WaitSynced {
+		logger.Info("waiting for the chain backend to sync")
+
+		err := transaction.WaitSynced(ctx, logger, backend, options.SyncMaxDelay)
+		if err != nil {
+			backend.Close()
+			return nil, common.Address{}, nil, fmt.Errorf("unable to wait for chain sync: %w", err)
+		}
+	} else {
+		synced, blockTime, err := transaction.IsSynced(ctx, backend, options.SyncMaxDelay)
+		if err != nil || !synced {
+			logger.Warn("chain backend not synced, last block at ", blockTime, " error: ", err)
+		}
+	}
+
+	ethAddress := signer.EthereumAddress()
+
+	txService, err := transaction.NewTxService(rpcClient, logger, *backend, signer, store, chainID, ethAddress, options.Monitor)
+	if err != nil {
+		return nil, common.Address{}, nil, fmt.Errorf("error bootstrapping transaction service: %w", err)
+	}
+
+	return rpcClient, ethAddress, &txService, nil
+}
 
+// dialEndpoint connects to a single endpoint and returns the chain it is on
+func dialEndpoint(ctx context.Context, logger *logrus.Logger, endpoint string) (*rpc.Client, *big.Int, error) {
 	rpcClient, err := rpc.DialContext(ctx, endpoint)
 	if err != nil {
-		logger.Error("unable to dial eth client: ", err)
-		return nil, common.Address{}, nil, fmt.Errorf("unable to dial eth client: %w", err)
+		return nil, nil, fmt.Errorf("unable to dial eth client: %w", err)
 	}
 
 	var versionString string
 
 	err = rpcClient.CallContext(ctx, &versionString, "web3_clientVersion")
 	if err != nil {
-		logger.Info("could not connect to backend, requires a working blockchain note, please check your endpoint: endpoint=", endpoint, "\n error: ", err)
-		return nil, common.Address{}, nil, fmt.Errorf("could not connect to backend, requires a working blockchain note, please check your endpoint: err: %w", err)
+		rpcClient.Close()
+		return nil, nil, fmt.Errorf("could not connect to backend, requires a working blockchain note, please check your endpoint: err: %w", err)
 	}
 
-	backend := transaction.NewBackend(ethclient.NewClient(rpcClient))
-
-	chainID, err := backend.ChainID(ctx)
+	chainID, err := ethclient.NewClient(rpcClient).ChainID(ctx)
 	if err != nil {
-		return nil, common.Address{}, nil, fmt.Errorf("could not get chain ID")
+		rpcClient.Close()
+		return nil, nil, fmt.Errorf("could not get chain ID: %w", err)
 	}
 
-	signer, err := signer.New(hexKey)
-	if err != nil {
-		return nil, common.Address{}, nil, err
+	logger.Info("connected to endpoint ", endpoint, " client: ", versionString, " chain: ", chainID)
+
+	return rpcClient, chainID, nil
+}
+
+// Endpoints returns the configured endpoints without duplicates, the primary endpoint comes first
+func Endpoints(config configuration.Crypto) []string {
+	seen := make(map[string]bool)
+	endpoints := make([]string, 0, len(config.Endpoints)+1)
+
+	for _, endpoint := range append([]string{config.Endpoint}, config.Endpoints...) {
+		if len(endpoint) == 0 || seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
 	}
 
-	ethAddress := signer.EthereumAddress()
+	return endpoints
+}
+
+// DefaultPasswordEnv is the environment variable holding the keystore password when none is configured
+const DefaultPasswordEnv = "GRYD_KEYSTORE_PASSWORD"
 
-	txService, err := transaction.NewTxService(rpcClient, logger, *backend, signer, chainID, ethAddress)
+// LoadSigner creates the signer of the node key, from the keystore when one is configured and from the
+// raw hex private key otherwise
+func LoadSigner(logger *logrus.Logger, config configuration.Crypto) (signer.Signer, error) {
+	if len(config.KeystorePath) == 0 {
+		if len(config.PrivateKey) == 0 {
+			return nil, errors.New("no keystore or private key configured")
+		}
+
+		logger.Warn("loading the node key from a raw private key, configure a keystore instead")
+		return signer.New(config.PrivateKey)
+	}
+
+	password, err := KeystorePassword(config)
 	if err != nil {
-		return nil, common.Address{}, nil, fmt.Errorf("error bootstrapping transaction service: %w", err)
+		return nil, err
 	}
 
-	return rpcClient, ethAddress, &txService, nil
+	return signer.LoadKeystore(config.KeystorePath, password)
+}
+
+// KeystorePassword reads the keystore password from the password file, or from the environment when no file is configured
+func KeystorePassword(config configuration.Crypto) (string, error) {
+	if len(config.PasswordFile) > 0 {
+		password, err := os.ReadFile(config.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read password file: %w", err)
+		}
+		return strings.TrimRight(string(password), "\r\n"), nil
+	}
+
+	env := config.PasswordEnv
+	if len(env) == 0 {
+		env = DefaultPasswordEnv
+	}
+
+	password, ok := os.LookupEnv(env)
+	if !ok {
+		return "", fmt.Errorf("keystore password not set, configure a password file or set %s", env)
+	}
+
+	return password, nil
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/sirupsen/logrus"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,6 +41,8 @@ type Database struct {
 	Ledger  orbitdb.DocumentStore
 
 	Events event.Subscription
+
+	loaded atomic.Bool
 }
 
 func NewDatabase(
@@ -145,6 +148,7 @@ func (d *Database) OrbitBootstrapper() error {
 		d.Logger.Error("error loading: %w", err)
 		return err
 	}
+	d.loaded.Store(true)
 
 	return nil
 }
@@ -161,3 +165,57 @@ func (d *Database) GetOwnPubKey() crypto.PubKey {
 
 	return pubKey
 }
+
+// Loaded reports whether the document store was loaded from its log
+func (d *Database) Loaded() bool {
+	return d.loaded.Load()
+}
+
+// Online reports whether the IPFS node is online
+func (d *Database) Online() bool {
+	return d.IPFSNode != nil && d.IPFSNode.IsOnline
+}
+
+// PeerCount returns the number of peers the IPFS node is connected to
+func (d *Database) PeerCount() int {
+	if !d.Online() {
+		return 0
+	}
+
+	return len(d.IPFSNode.PeerHost.Network().Peers())
+}
+
+// StoreAddresses returns the orbitdb addresses of the document store and the ledger
+func (d *Database) StoreAddresses() (string, string) {
+	return d.Store.Address().String(), d.Ledger.Address().String()
+}
+
+// Close closes the stores, then orbitdb and finally the IPFS node they are replicated over
+func (d *Database) Close() error {
+	var errs []error
+
+	if d.Events != nil {
+		errs = append(errs, d.Events.Close())
+	}
+	if d.Store != nil {
+		errs = append(errs, d.Store.Close())
+	}
+	if d.Ledger != nil {
+		errs = append(errs, d.Ledger.Close())
+	}
+	if d.OrbitDB != nil {
+		errs = append(errs, d.OrbitDB.Close())
+	}
+	if d.IPFSNode != nil {
+		errs = append(errs, d.IPFSNode.Close())
+	}
+	d.loaded.Store(false)
+
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("unable to close odb: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gryd-database/platform-poc/configuration"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -12,8 +13,18 @@ func InitPool(config *configuration.Config) (*pgxpool.Pool, error) {
 	args := config.Postgres
 	databaseUrl := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", args.DBUsername, args.Password, args.Host, args.Port, args.DBName)
 
+	poolConfig, err := pgxpool.ParseConfig(databaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pg config: %w", err)
+	}
+
+	if config.Tracing.Exporter != "" {
+		poolConfig.ConnConfig.Logger = queryTracer{}
+		poolConfig.ConnConfig.LogLevel = pgx.LogLevelInfo
+	}
+
 	// this returns connection pool
-	pool, err := pgxpool.Connect(context.Background(), databaseUrl)
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error bootstrapping pg: %w", err)
 	}
@@ -0,0 +1,55 @@
+package pg
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gryd-database/platform-poc/pkg/pg")
+
+// queryTracer turns the query logs of pgx into spans, pgx v4 only reports a query once it completed so its span
+// starts back at the time the query was sent. Queries outside of a trace are not traced.
+type queryTracer struct{}
+
+func (queryTracer) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	switch msg {
+	case "Query", "Exec", "SendBatch":
+	default:
+		return
+	}
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	end := time.Now()
+	elapsed, _ := data["time"].(time.Duration)
+
+	attributes := []attribute.KeyValue{semconv.DBSystemPostgreSQL}
+	if sql, ok := data["sql"].(string); ok {
+		attributes = append(attributes, semconv.DBStatementKey.String(sql))
+	}
+	if rowCount, ok := data["rowCount"].(int); ok {
+		attributes = append(attributes, attribute.Int("db.row_count", rowCount))
+	}
+
+	_, span := tracer.Start(ctx, "postgres "+strings.ToLower(msg),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-elapsed)),
+		trace.WithAttributes(attributes...))
+
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,92 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+)
+
+// NewFromKeystore creates a signer from a go-ethereum V3 keystore file encrypted with the password
+func NewFromKeystore(keyJSON []byte, password string) (Signer, error) {
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt keystore: %w", err)
+	}
+
+	return newSigner(key.PrivateKey), nil
+}
+
+// LoadKeystore reads the keystore file at path and creates a signer from it
+func LoadKeystore(path, password string) (Signer, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keystore: %w", err)
+	}
+
+	return NewFromKeystore(keyJSON, password)
+}
+
+// GenerateKeystore creates a new key encrypted with the password and writes it as a V3 keystore file to path,
+// an existing file is never overwritten and a failed write never leaves a partial file at path
+func GenerateKeystore(path, password string) (Signer, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key id: %w", err)
+	}
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, password, keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt key: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create keystore directory: %w", err)
+	}
+
+	// the key is written to a temporary file of the same directory first, it only shows up at path once complete
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create keystore: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(keyJSON)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to write keystore: %w", err)
+	}
+
+	// a hard link moves the file into place like a rename but fails instead of replacing an existing keystore
+	err = os.Link(file.Name(), path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create keystore: %w", err)
+	}
+
+	return newSigner(privateKey), nil
+}
+
+func newSigner(privateKey *ecdsa.PrivateKey) Signer {
+	return &defaultSigner{
+		privateKey: privateKey,
+		publicKey:  &privateKey.PublicKey,
+	}
+}
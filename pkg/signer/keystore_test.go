@@ -0,0 +1,59 @@
+package signer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keystore", "node.json")
+
+	generated, err := GenerateKeystore(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		loaded, err := LoadKeystore(path, "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.EthereumAddress() != generated.EthereumAddress() {
+			t.Fatalf("expected address %s, got %s", generated.EthereumAddress().Hex(), loaded.EthereumAddress().Hex())
+		}
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := LoadKeystore(path, "wrong")
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := GenerateKeystore(path, "secret")
+		if !errors.Is(err, os.ErrExist) {
+			t.Fatalf("expected existing file error, got %v", err)
+		}
+
+		// the temporary file is removed when the keystore cannot be moved into place
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("expected only the keystore in its directory, got %d entries", len(entries))
+		}
+	})
+}
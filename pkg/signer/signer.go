@@ -3,13 +3,23 @@ package signer
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
 )
 
 type Signer interface {
 	// EthereumAddress returns the ethereum address this signer uses.
 	EthereumAddress() common.Address
+	// SignTx signs an ethereum transaction for the given chain.
+	SignTx(transaction *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// Sign signs data with the EIP-191 ethereum prefix, the way personal_sign does.
+	Sign(data []byte) ([]byte, error)
+	// SignTypedData signs EIP-712 typed data, the way eth_signTypedData_v4 does.
+	SignTypedData(typedData *apitypes.TypedData) ([]byte, error)
 }
 
 type defaultSigner struct {
@@ -21,6 +31,41 @@ func (d *defaultSigner) EthereumAddress() common.Address {
 	return crypto.PubkeyToAddress(*d.publicKey)
 }
 
+func (d *defaultSigner) SignTx(transaction *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signedTx, err := types.SignTx(transaction, types.LatestSignerForChainID(chainID), d.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign transaction: %w", err)
+	}
+
+	return signedTx, nil
+}
+
+func (d *defaultSigner) Sign(data []byte) ([]byte, error) {
+	return d.sign(accounts.TextHash(data))
+}
+
+func (d *defaultSigner) SignTypedData(typedData *apitypes.TypedData) ([]byte, error) {
+	sighash, _, err := apitypes.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to hash typed data: %w", err)
+	}
+
+	return d.sign(sighash)
+}
+
+// sign signs the hash and returns the signature in the [R || S || V] format with V as 27 or 28,
+// like wallets do for personal_sign and eth_signTypedData
+func (d *defaultSigner) sign(sighash []byte) ([]byte, error) {
+	signature, err := crypto.Sign(sighash, d.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign: %w", err)
+	}
+
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
+
 func New(hexKey string) (Signer, error) {
 	privateKey, err := crypto.HexToECDSA(hexKey)
 	if err != nil {
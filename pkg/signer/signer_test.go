@@ -1,8 +1,14 @@
 package signer
 
 import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
 	"testing"
 )
 
@@ -60,3 +66,113 @@ func TestSigner(t *testing.T) {
 		}
 	})
 }
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := New(hexutil.Encode(crypto.FromECDSA(privateKey))[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("sign tx", func(t *testing.T) {
+		t.Parallel()
+
+		chainID := big.NewInt(5)
+		to := common.HexToAddress("0xabcd")
+		tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Gas: 21000, To: &to, Value: big.NewInt(1)})
+
+		signedTx, err := signer.SignTx(tx, chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sender != signer.EthereumAddress() {
+			t.Fatalf("expected sender %s, got %s", signer.EthereumAddress().Hex(), sender.Hex())
+		}
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		t.Parallel()
+
+		data := []byte("hello gryd")
+
+		signature, err := signer.Sign(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v := signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+			t.Fatalf("expected v of 27 or 28, got %d", v)
+		}
+
+		assertSigner(t, accounts.TextHash(data), signature, signer.EthereumAddress())
+	})
+
+	t.Run("sign typed data", func(t *testing.T) {
+		t.Parallel()
+
+		typedData := &apitypes.TypedData{
+			Types: apitypes.Types{
+				"EIP712Domain": {
+					{Name: "name", Type: "string"},
+					{Name: "version", Type: "string"},
+					{Name: "chainId", Type: "uint256"},
+				},
+				"Receipt": {
+					{Name: "datasetKey", Type: "string"},
+					{Name: "wallet", Type: "address"},
+				},
+			},
+			PrimaryType: "Receipt",
+			Domain: apitypes.TypedDataDomain{
+				Name:    "GRYD",
+				Version: "1",
+				ChainId: math.NewHexOrDecimal256(5),
+			},
+			Message: apitypes.TypedDataMessage{
+				"datasetKey": "b6f1a6b2",
+				"wallet":     "0xD07708ad91fbE34329507E2adABfb31534dD3efd",
+			},
+		}
+
+		signature, err := signer.SignTypedData(typedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sighash, _, err := apitypes.TypedDataAndHash(*typedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertSigner(t, sighash, signature, signer.EthereumAddress())
+	})
+}
+
+func assertSigner(t *testing.T, sighash, signature []byte, expected common.Address) {
+	t.Helper()
+
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+
+	publicKey, err := crypto.SigToPub(sighash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address := crypto.PubkeyToAddress(*publicKey); address != expected {
+		t.Fatalf("expected signer %s, got %s", expected.Hex(), address.Hex())
+	}
+}
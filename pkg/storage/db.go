@@ -5,6 +5,7 @@ import (
 	"fmt"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"time"
@@ -12,8 +13,32 @@ import (
 
 type DBService interface {
 	Create(ctx context.Context, voStorage *VoStorage) (*DTOStorage, error)
+	CreateTombstone(ctx context.Context, voTombstone *VoTombstone) (*DTOTombstone, error)
+	GetTombstoneByTxHash(ctx context.Context, txHash string) (*DTOTombstone, error)
+	ListTombstonesByDatasetKey(ctx context.Context, datasetKey string) ([]DTOTombstone, error)
+	DeleteTombstone(ctx context.Context, id uuid.UUID) error
+	ListByWallet(ctx context.Context, wallet string, limit, offset int) ([]DTOStorage, error)
+	ListDatasetKeysByWallet(ctx context.Context, wallet string) ([]string, error)
+	GetByTxHash(ctx context.Context, txHash string) (*DTOStorage, error)
+	GetByDatasetKey(ctx context.Context, datasetKey string) (*DTOStorage, error)
+	GetQuota(ctx context.Context, wallet string) (*DTOQuota, error)
+	AdjustQuota(ctx context.Context, wallet string, rows, bytes int64, limits QuotaLimits) (*DTOQuota, error)
+	ListPending(ctx context.Context, limit int) ([]DTOStorage, error)
+	SetStatus(ctx context.Context, id uuid.UUID, status string) error
+	SaveEvents(ctx context.Context, contract string, events []DTOEvent, lastBlock uint64) error
+	LastIndexedBlock(ctx context.Context, contract string) (uint64, error)
+	GetEventsByTxHash(ctx context.Context, txHash string) ([]DTOEvent, error)
 }
 
+var (
+	ErrStorageNotFound   = errors.New("storage not found")
+	ErrTombstoneNotFound = errors.New("tombstone not found")
+	ErrDuplicateTxHash   = errors.New("tx hash already used")
+)
+
+// pgUniqueViolation is the postgres error code raised when a unique index rejects a row
+const pgUniqueViolation = "23505"
+
 //nolint:golint,gochecknoglobals,varnamelen
 var QB = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
@@ -30,6 +55,26 @@ type DTOStorage struct {
 	TxHash     string    `json:"txHash"`
 	CreatedAt  time.Time `json:"createdAt"`
 	DatasetKey string    `json:"datasetKey"`
+	Status     string    `json:"status"`
+}
+
+// VoTombstone records the tx that paid for an update or delete of a dataset, RecordID is empty when the whole dataset was affected
+type VoTombstone struct {
+	Wallet     string `json:"wallet"`
+	TxHash     string `json:"txHash"`
+	DatasetKey string `json:"datasetKey"`
+	RecordID   string `json:"recordId"`
+	QueryType  string `json:"queryType"`
+}
+
+type DTOTombstone struct {
+	ID         uuid.UUID `json:"id"`
+	Wallet     string    `json:"wallet"`
+	TxHash     string    `json:"txHash"`
+	DatasetKey string    `json:"datasetKey"`
+	RecordID   string    `json:"recordId"`
+	QueryType  string    `json:"queryType"`
+	CreatedAt  time.Time `json:"createdAt"`
 }
 
 func (s *Storage) Create(ctx context.Context, voStorage *VoStorage) (*DTOStorage, error) {
@@ -45,7 +90,7 @@ func (s *Storage) create(ctx context.Context, voStorage *VoStorage) (*DTOStorage
 	sqls, args, err := QB.Insert("storage").
 		Columns("wallet", "txHash", "datasetKey").
 		Values(voStorage.Wallet, voStorage.TxHash, voStorage.DatasetKey).
-		Suffix("RETURNING *").
+		Suffix("RETURNING id, wallet, txHash, createdAt, datasetKey, status").
 		ToSql()
 	if err != nil {
 		return &DTOStorage{}, fmt.Errorf("error building query for create storage: %w", err)
@@ -54,12 +99,16 @@ func (s *Storage) create(ctx context.Context, voStorage *VoStorage) (*DTOStorage
 	var dtoStorage DTOStorage
 
 	rows, err := s.pg.Query(ctx, sqls, args...)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicateTxHash
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error executing query for create storage: %w", err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		err := rows.Scan(&dtoStorage.ID, &dtoStorage.Wallet, &dtoStorage.TxHash, &dtoStorage.CreatedAt, &dtoStorage.DatasetKey)
+		err := rows.Scan(&dtoStorage.ID, &dtoStorage.Wallet, &dtoStorage.TxHash, &dtoStorage.CreatedAt, &dtoStorage.DatasetKey, &dtoStorage.Status)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return nil, fmt.Errorf("cannot fetch created rows: %w", err)
@@ -69,5 +118,226 @@ func (s *Storage) create(ctx context.Context, voStorage *VoStorage) (*DTOStorage
 
 	}
 
+	// the unique index on txHash may only be reported once the rows are read
+	if isUniqueViolation(rows.Err()) {
+		return nil, ErrDuplicateTxHash
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for create storage: %w", rows.Err())
+	}
+
+	return &dtoStorage, nil
+}
+
+// ListByWallet returns the uploads of the wallet, newest first
+func (s *Storage) ListByWallet(ctx context.Context, wallet string, limit, offset int) ([]DTOStorage, error) {
+	sqls, args, err := storageSelect().
+		Where(sq.Expr("lower(wallet) = lower(?)", wallet)).
+		OrderBy("createdAt DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for list storage by wallet: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for list storage by wallet: %w", err)
+	}
+	defer rows.Close()
+
+	dtoStorages := []DTOStorage{}
+	for rows.Next() {
+		var dtoStorage DTOStorage
+		err := rows.Scan(&dtoStorage.ID, &dtoStorage.Wallet, &dtoStorage.TxHash, &dtoStorage.CreatedAt, &dtoStorage.DatasetKey, &dtoStorage.Status)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for list storage by wallet: %w", err)
+		}
+		dtoStorages = append(dtoStorages, dtoStorage)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for list storage by wallet: %w", rows.Err())
+	}
+
+	return dtoStorages, nil
+}
+
+// ListDatasetKeysByWallet returns the keys of every dataset uploaded by the wallet
+func (s *Storage) ListDatasetKeysByWallet(ctx context.Context, wallet string) ([]string, error) {
+	sqls, args, err := QB.Select("datasetKey").
+		From("storage").
+		Where(sq.Expr("lower(wallet) = lower(?)", wallet)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for list dataset keys by wallet: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for list dataset keys by wallet: %w", err)
+	}
+	defer rows.Close()
+
+	datasetKeys := []string{}
+	for rows.Next() {
+		var datasetKey string
+		err := rows.Scan(&datasetKey)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for list dataset keys by wallet: %w", err)
+		}
+		datasetKeys = append(datasetKeys, datasetKey)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for list dataset keys by wallet: %w", rows.Err())
+	}
+
+	return datasetKeys, nil
+}
+
+func (s *Storage) GetByTxHash(ctx context.Context, txHash string) (*DTOStorage, error) {
+	return s.getBy(ctx, sq.Expr("lower(txHash) = lower(?)", txHash))
+}
+
+func (s *Storage) GetByDatasetKey(ctx context.Context, datasetKey string) (*DTOStorage, error) {
+	return s.getBy(ctx, sq.Eq{"datasetKey": datasetKey})
+}
+
+func (s *Storage) getBy(ctx context.Context, pred interface{}) (*DTOStorage, error) {
+	sqls, args, err := storageSelect().Where(pred).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for get storage: %w", err)
+	}
+
+	var dtoStorage DTOStorage
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&dtoStorage.ID, &dtoStorage.Wallet, &dtoStorage.TxHash, &dtoStorage.CreatedAt, &dtoStorage.DatasetKey, &dtoStorage.Status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStorageNotFound
+		}
+		return nil, fmt.Errorf("error executing query for get storage: %w", err)
+	}
+
 	return &dtoStorage, nil
 }
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+func storageSelect() sq.SelectBuilder {
+	return QB.Select("id", "wallet", "txHash", "createdAt", "datasetKey", "status").From("storage")
+}
+
+func (s *Storage) CreateTombstone(ctx context.Context, voTombstone *VoTombstone) (*DTOTombstone, error) {
+	resp, err := s.createTombstone(ctx, voTombstone)
+	if err != nil {
+		return resp, fmt.Errorf("unable to store tombstone in db: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (s *Storage) createTombstone(ctx context.Context, voTombstone *VoTombstone) (*DTOTombstone, error) {
+	sqls, args, err := QB.Insert("tombstone").
+		Columns("wallet", "txHash", "datasetKey", "recordId", "queryType").
+		Values(voTombstone.Wallet, voTombstone.TxHash, voTombstone.DatasetKey, voTombstone.RecordID, voTombstone.QueryType).
+		Suffix("RETURNING id, wallet, txHash, datasetKey, recordId, queryType, createdAt").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for create tombstone: %w", err)
+	}
+
+	var dtoTombstone DTOTombstone
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(
+		&dtoTombstone.ID, &dtoTombstone.Wallet, &dtoTombstone.TxHash, &dtoTombstone.DatasetKey,
+		&dtoTombstone.RecordID, &dtoTombstone.QueryType, &dtoTombstone.CreatedAt)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicateTxHash
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for create tombstone: %w", err)
+	}
+
+	return &dtoTombstone, nil
+}
+
+func (s *Storage) GetTombstoneByTxHash(ctx context.Context, txHash string) (*DTOTombstone, error) {
+	sqls, args, err := QB.Select("id", "wallet", "txHash", "datasetKey", "recordId", "queryType", "createdAt").
+		From("tombstone").
+		Where(sq.Expr("lower(txHash) = lower(?)", txHash)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for get tombstone: %w", err)
+	}
+
+	var dtoTombstone DTOTombstone
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(
+		&dtoTombstone.ID, &dtoTombstone.Wallet, &dtoTombstone.TxHash, &dtoTombstone.DatasetKey,
+		&dtoTombstone.RecordID, &dtoTombstone.QueryType, &dtoTombstone.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTombstoneNotFound
+		}
+		return nil, fmt.Errorf("error executing query for get tombstone: %w", err)
+	}
+
+	return &dtoTombstone, nil
+}
+
+// ListTombstonesByDatasetKey returns the updates and deletes of a dataset, oldest first
+func (s *Storage) ListTombstonesByDatasetKey(ctx context.Context, datasetKey string) ([]DTOTombstone, error) {
+	sqls, args, err := QB.Select("id", "wallet", "txHash", "datasetKey", "recordId", "queryType", "createdAt").
+		From("tombstone").
+		Where(sq.Eq{"datasetKey": datasetKey}).
+		OrderBy("createdAt").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for list tombstones: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for list tombstones: %w", err)
+	}
+	defer rows.Close()
+
+	var dtoTombstones []DTOTombstone
+	for rows.Next() {
+		var dtoTombstone DTOTombstone
+		err = rows.Scan(
+			&dtoTombstone.ID, &dtoTombstone.Wallet, &dtoTombstone.TxHash, &dtoTombstone.DatasetKey,
+			&dtoTombstone.RecordID, &dtoTombstone.QueryType, &dtoTombstone.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning tombstone: %w", err)
+		}
+		dtoTombstones = append(dtoTombstones, dtoTombstone)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading tombstones: %w", err)
+	}
+
+	return dtoTombstones, nil
+}
+
+// DeleteTombstone releases the tx hash of a tombstone whose mutation could not be applied
+func (s *Storage) DeleteTombstone(ctx context.Context, id uuid.UUID) error {
+	sqls, args, err := QB.Delete("tombstone").Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query for delete tombstone: %w", err)
+	}
+
+	_, err = s.pg.Exec(ctx, sqls, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query for delete tombstone: %w", err)
+	}
+
+	return nil
+}
@@ -2,17 +2,93 @@ package dbMock
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/gryd-database/platform-poc/pkg/storage"
 )
 
 type dbMock struct {
-	create func(ctx context.Context, voStorage *storage.VoStorage) (*storage.DTOStorage, error)
+	create            func(ctx context.Context, voStorage *storage.VoStorage) (*storage.DTOStorage, error)
+	createTombstone   func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error)
+	getTombstone      func(ctx context.Context, txHash string) (*storage.DTOTombstone, error)
+	listTombstones    func(ctx context.Context, datasetKey string) ([]storage.DTOTombstone, error)
+	deleteTombstone   func(ctx context.Context, id uuid.UUID) error
+	listByWallet      func(ctx context.Context, wallet string, limit, offset int) ([]storage.DTOStorage, error)
+	listDatasetKeys   func(ctx context.Context, wallet string) ([]string, error)
+	getByTxHash       func(ctx context.Context, txHash string) (*storage.DTOStorage, error)
+	getByDatasetKey   func(ctx context.Context, datasetKey string) (*storage.DTOStorage, error)
+	getQuota          func(ctx context.Context, wallet string) (*storage.DTOQuota, error)
+	adjustQuota       func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error)
+	saveEvents        func(ctx context.Context, contract string, events []storage.DTOEvent, lastBlock uint64) error
+	lastIndexedBlock  func(ctx context.Context, contract string) (uint64, error)
+	getEventsByTxHash func(ctx context.Context, txHash string) ([]storage.DTOEvent, error)
+	listPending       func(ctx context.Context, limit int) ([]storage.DTOStorage, error)
+	setStatus         func(ctx context.Context, id uuid.UUID, status string) error
 }
 
 func (s *dbMock) Create(ctx context.Context, voStorage *storage.VoStorage) (*storage.DTOStorage, error) {
 	return s.create(ctx, voStorage)
 }
 
+func (s *dbMock) CreateTombstone(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error) {
+	return s.createTombstone(ctx, voTombstone)
+}
+
+func (s *dbMock) GetTombstoneByTxHash(ctx context.Context, txHash string) (*storage.DTOTombstone, error) {
+	return s.getTombstone(ctx, txHash)
+}
+
+func (s *dbMock) ListTombstonesByDatasetKey(ctx context.Context, datasetKey string) ([]storage.DTOTombstone, error) {
+	return s.listTombstones(ctx, datasetKey)
+}
+
+func (s *dbMock) DeleteTombstone(ctx context.Context, id uuid.UUID) error {
+	return s.deleteTombstone(ctx, id)
+}
+
+func (s *dbMock) ListByWallet(ctx context.Context, wallet string, limit, offset int) ([]storage.DTOStorage, error) {
+	return s.listByWallet(ctx, wallet, limit, offset)
+}
+
+func (s *dbMock) ListDatasetKeysByWallet(ctx context.Context, wallet string) ([]string, error) {
+	return s.listDatasetKeys(ctx, wallet)
+}
+
+func (s *dbMock) GetByTxHash(ctx context.Context, txHash string) (*storage.DTOStorage, error) {
+	return s.getByTxHash(ctx, txHash)
+}
+
+func (s *dbMock) GetByDatasetKey(ctx context.Context, datasetKey string) (*storage.DTOStorage, error) {
+	return s.getByDatasetKey(ctx, datasetKey)
+}
+
+func (s *dbMock) GetQuota(ctx context.Context, wallet string) (*storage.DTOQuota, error) {
+	return s.getQuota(ctx, wallet)
+}
+
+func (s *dbMock) AdjustQuota(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+	return s.adjustQuota(ctx, wallet, rows, bytes, limits)
+}
+
+func (s *dbMock) SaveEvents(ctx context.Context, contract string, events []storage.DTOEvent, lastBlock uint64) error {
+	return s.saveEvents(ctx, contract, events, lastBlock)
+}
+
+func (s *dbMock) LastIndexedBlock(ctx context.Context, contract string) (uint64, error) {
+	return s.lastIndexedBlock(ctx, contract)
+}
+
+func (s *dbMock) GetEventsByTxHash(ctx context.Context, txHash string) ([]storage.DTOEvent, error) {
+	return s.getEventsByTxHash(ctx, txHash)
+}
+
+func (s *dbMock) ListPending(ctx context.Context, limit int) ([]storage.DTOStorage, error) {
+	return s.listPending(ctx, limit)
+}
+
+func (s *dbMock) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return s.setStatus(ctx, id, status)
+}
+
 type Option func(mock *dbMock)
 
 // New creates a new mock
@@ -31,3 +107,93 @@ func WithCreate(f func(ctx context.Context, voStorage *storage.VoStorage) (*stor
 		mock.create = f
 	}
 }
+
+func WithCreateTombstone(f func(ctx context.Context, voTombstone *storage.VoTombstone) (*storage.DTOTombstone, error)) Option {
+	return func(mock *dbMock) {
+		mock.createTombstone = f
+	}
+}
+
+func WithGetTombstoneByTxHash(f func(ctx context.Context, txHash string) (*storage.DTOTombstone, error)) Option {
+	return func(mock *dbMock) {
+		mock.getTombstone = f
+	}
+}
+
+func WithListTombstonesByDatasetKey(f func(ctx context.Context, datasetKey string) ([]storage.DTOTombstone, error)) Option {
+	return func(mock *dbMock) {
+		mock.listTombstones = f
+	}
+}
+
+func WithDeleteTombstone(f func(ctx context.Context, id uuid.UUID) error) Option {
+	return func(mock *dbMock) {
+		mock.deleteTombstone = f
+	}
+}
+
+func WithListByWallet(f func(ctx context.Context, wallet string, limit, offset int) ([]storage.DTOStorage, error)) Option {
+	return func(mock *dbMock) {
+		mock.listByWallet = f
+	}
+}
+
+func WithListDatasetKeysByWallet(f func(ctx context.Context, wallet string) ([]string, error)) Option {
+	return func(mock *dbMock) {
+		mock.listDatasetKeys = f
+	}
+}
+
+func WithGetByTxHash(f func(ctx context.Context, txHash string) (*storage.DTOStorage, error)) Option {
+	return func(mock *dbMock) {
+		mock.getByTxHash = f
+	}
+}
+
+func WithGetByDatasetKey(f func(ctx context.Context, datasetKey string) (*storage.DTOStorage, error)) Option {
+	return func(mock *dbMock) {
+		mock.getByDatasetKey = f
+	}
+}
+
+func WithGetQuota(f func(ctx context.Context, wallet string) (*storage.DTOQuota, error)) Option {
+	return func(mock *dbMock) {
+		mock.getQuota = f
+	}
+}
+
+func WithAdjustQuota(f func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error)) Option {
+	return func(mock *dbMock) {
+		mock.adjustQuota = f
+	}
+}
+
+func WithSaveEvents(f func(ctx context.Context, contract string, events []storage.DTOEvent, lastBlock uint64) error) Option {
+	return func(mock *dbMock) {
+		mock.saveEvents = f
+	}
+}
+
+func WithLastIndexedBlock(f func(ctx context.Context, contract string) (uint64, error)) Option {
+	return func(mock *dbMock) {
+		mock.lastIndexedBlock = f
+	}
+}
+
+func WithGetEventsByTxHash(f func(ctx context.Context, txHash string) ([]storage.DTOEvent, error)) Option {
+	return func(mock *dbMock) {
+		mock.getEventsByTxHash = f
+	}
+}
+
+func WithListPending(f func(ctx context.Context, limit int) ([]storage.DTOStorage, error)) Option {
+	return func(mock *dbMock) {
+		mock.listPending = f
+	}
+}
+
+func WithSetStatus(f func(ctx context.Context, id uuid.UUID, status string) error) Option {
+	return func(mock *dbMock) {
+		mock.setStatus = f
+	}
+}
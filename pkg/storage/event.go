@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrEventNotIndexed = errors.New("event not indexed")
+)
+
+// DTOEvent is an InsertDataSuccess event ingested by the indexer
+type DTOEvent struct {
+	TxHash      string    `json:"txHash"`
+	LogIndex    uint      `json:"logIndex"`
+	BlockNumber uint64    `json:"blockNumber"`
+	BlockHash   string    `json:"blockHash"`
+	Wallet      string    `json:"wallet"`
+	QueryType   string    `json:"queryType"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// SaveEvents stores the events and advances the last indexed block of the contract in a single transaction,
+// events that were already stored are left untouched
+func (s *Storage) SaveEvents(ctx context.Context, contract string, events []DTOEvent, lastBlock uint64) error {
+	err := s.pg.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if len(events) > 0 {
+			insert := QB.Insert("event").
+				Columns("txHash", "logIndex", "blockNumber", "blockHash", "wallet", "queryType")
+			for _, event := range events {
+				insert = insert.Values(event.TxHash, event.LogIndex, event.BlockNumber, event.BlockHash, event.Wallet, event.QueryType)
+			}
+
+			sqls, args, err := insert.Suffix("ON CONFLICT (txHash, logIndex) DO NOTHING").ToSql()
+			if err != nil {
+				return fmt.Errorf("error building query for save events: %w", err)
+			}
+
+			if _, err := tx.Exec(ctx, sqls, args...); err != nil {
+				return fmt.Errorf("error executing query for save events: %w", err)
+			}
+		}
+
+		sqls, args, err := QB.Insert("indexer").
+			Columns("contract", "lastBlock").
+			Values(contract, lastBlock).
+			Suffix("ON CONFLICT (contract) DO UPDATE SET lastBlock = EXCLUDED.lastBlock, updatedAt = CURRENT_TIMESTAMP").
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("error building query for save last indexed block: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, sqls, args...); err != nil {
+			return fmt.Errorf("error executing query for save last indexed block: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to save events: %w", err)
+	}
+
+	return nil
+}
+
+// LastIndexedBlock returns the last block the indexer processed for the contract, 0 when it never ran
+func (s *Storage) LastIndexedBlock(ctx context.Context, contract string) (uint64, error) {
+	sqls, args, err := QB.Select("lastBlock").
+		From("indexer").
+		Where(sq.Eq{"contract": contract}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("error building query for last indexed block: %w", err)
+	}
+
+	var lastBlock uint64
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&lastBlock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error executing query for last indexed block: %w", err)
+	}
+
+	return lastBlock, nil
+}
+
+// GetEventsByTxHash returns the indexed events emitted by the tx in log order, ErrEventNotIndexed when there are none
+func (s *Storage) GetEventsByTxHash(ctx context.Context, txHash string) ([]DTOEvent, error) {
+	sqls, args, err := QB.Select("txHash", "logIndex", "blockNumber", "blockHash", "wallet", "queryType", "createdAt").
+		From("event").
+		Where(sq.Expr("lower(txHash) = lower(?)", txHash)).
+		OrderBy("logIndex").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for get events by tx hash: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for get events by tx hash: %w", err)
+	}
+	defer rows.Close()
+
+	var events []DTOEvent
+	for rows.Next() {
+		var event DTOEvent
+		err := rows.Scan(&event.TxHash, &event.LogIndex, &event.BlockNumber, &event.BlockHash, &event.Wallet, &event.QueryType, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for get events by tx hash: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for get events by tx hash: %w", rows.Err())
+	}
+
+	if len(events) == 0 {
+		return nil, ErrEventNotIndexed
+	}
+
+	return events, nil
+}
@@ -5,19 +5,30 @@ import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gryd-database/platform-poc/pkg/transaction"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"math/big"
 )
 
+// query types emitted in the InsertDataSuccess event
+const (
+	QueryTypeCreate = "create"
+	QueryTypeUpdate = "update"
+	QueryTypeDelete = "delete"
+)
+
 var (
 	ErrUnprocessableEvent = errors.New("event cannot be processed or does not exist")
+	ErrEventNotConfirmed  = errors.New("event not confirmed yet")
+	ErrBlockNotCanonical  = errors.New("block of the tx is no longer canonical")
 )
 
 type GRYDContract interface {
 	GetBalance(ctx context.Context) (*big.Int, error)
-	VerifyEvent(ctx context.Context, hashTx string) (*EventInsertDataSuccess, error)
+	// VerifyEvent returns the InsertDataSuccess events emitted by a successful tx, a tx paying for a batch emits several
+	VerifyEvent(ctx context.Context, hashTx string) ([]EventInsertDataSuccess, error)
 }
 
 type Contract struct {
@@ -25,6 +36,7 @@ type Contract struct {
 	grydContractAddress common.Address
 	grydContractABI     abi.ABI
 	owner               common.Address
+	confirmations       uint64
 	logger              *logrus.Logger
 
 	Events Events
@@ -39,12 +51,14 @@ type EventInsertDataSuccess struct {
 	QueryType string
 }
 
-func NewContract(txService *transaction.Service, owner common.Address, logger *logrus.Logger, grydAddress common.Address, grydABI abi.ABI) GRYDContract {
+// NewContract creates the gryd contract service, events are only accepted once their block is confirmations blocks deep
+func NewContract(txService *transaction.Service, owner common.Address, logger *logrus.Logger, grydAddress common.Address, grydABI abi.ABI, confirmations uint64) GRYDContract {
 	return &Contract{
 		txService:           *txService,
 		grydContractAddress: grydAddress,
 		grydContractABI:     grydABI,
 		owner:               owner,
+		confirmations:       confirmations,
 		Events: Events{
 			TopicInsertDataSuccess: grydABI.Events["InsertDataSuccess"].ID,
 		},
@@ -78,24 +92,60 @@ func (s *Contract) GetBalance(ctx context.Context) (*big.Int, error) {
 	return abi.ConvertType(results[0], new(big.Int)).(*big.Int), nil
 }
 
-func (s *Contract) VerifyEvent(ctx context.Context, hashTx string) (*EventInsertDataSuccess, error) {
+func (s *Contract) VerifyEvent(ctx context.Context, hashTx string) ([]EventInsertDataSuccess, error) {
 	receipt, err := s.txService.WaitForReceipt(ctx, common.HexToHash(hashTx))
 	if err != nil {
 		return nil, fmt.Errorf("error getting the receipt from tx hash: %s with error: %w", hashTx, err)
 	}
 
-	var event EventInsertDataSuccess
+	logs, err := transaction.FindEvents(receipt, s.grydContractAddress, s.grydContractABI.Events["InsertDataSuccess"])
+	if err != nil {
+		return nil, fmt.Errorf("error finding events of tx hash: %s with error: %w", hashTx, err)
+	}
 
-	for _, ev := range receipt.Logs {
-		if ev.Address == s.grydContractAddress && len(ev.Topics) > 0 && ev.Topics[0] == s.Events.TopicInsertDataSuccess {
-			err = transaction.ParseEvent(&s.grydContractABI, "InsertDataSuccess", &event, *ev)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing event of hash: %s with error: %w", hashTx, err)
-			}
-		} else {
-			return nil, ErrUnprocessableEvent
+	err = s.checkConfirmed(ctx, receipt)
+	if err != nil {
+		return nil, fmt.Errorf("error confirming tx hash: %s with error: %w", hashTx, err)
+	}
+
+	events := make([]EventInsertDataSuccess, 0, len(logs))
+	for _, log := range logs {
+		var event EventInsertDataSuccess
+		err = transaction.ParseEvent(&s.grydContractABI, "InsertDataSuccess", &event, log)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error parsing event of hash: %s with error: %v", ErrUnprocessableEvent, hashTx, err)
 		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// checkConfirmed makes sure the block of the receipt is deep enough and still part of the canonical chain,
+// receipts are accepted as is when no confirmations are required
+func (s *Contract) checkConfirmed(ctx context.Context, receipt *types.Receipt) error {
+	if s.confirmations == 0 {
+		return nil
+	}
+
+	head, err := s.txService.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get block number: %w", err)
+	}
+
+	blockNumber := receipt.BlockNumber.Uint64()
+	if head < blockNumber || head-blockNumber < s.confirmations {
+		return ErrEventNotConfirmed
+	}
+
+	header, err := s.txService.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("unable to get header of block %d: %w", blockNumber, err)
+	}
+
+	if header.Hash() != receipt.BlockHash {
+		return ErrBlockNotCanonical
 	}
 
-	return &event, nil
+	return nil
 }
@@ -8,10 +8,10 @@ import (
 
 type grydContractMock struct {
 	getBalance  func(ctx context.Context) (*big.Int, error)
-	verifyEvent func(ctx context.Context, hashTx string) (*storage.EventInsertDataSuccess, error)
+	verifyEvent func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error)
 }
 
-func (g *grydContractMock) VerifyEvent(ctx context.Context, hashTx string) (*storage.EventInsertDataSuccess, error) {
+func (g *grydContractMock) VerifyEvent(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
 	return g.verifyEvent(ctx, hashTx)
 }
 
@@ -39,7 +39,7 @@ func WithGetBalance(f func(ctx context.Context) (*big.Int, error)) Option {
 	}
 }
 
-func WithVerifyEvent(f func(ctx context.Context, hashTx string) (*storage.EventInsertDataSuccess, error)) Option {
+func WithVerifyEvent(f func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error)) Option {
 	return func(mock *grydContractMock) {
 		mock.verifyEvent = f
 	}
@@ -47,7 +47,7 @@ func TestGetBalance(t *testing.T) {
 		}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), grydAddress, grydContractABI)
+			&txService, owner, logrus.New(), grydAddress, grydContractABI, 0)
 
 		_, err = contract.GetBalance(ctx)
 		if err != nil {
@@ -73,7 +73,7 @@ func TestGetBalance(t *testing.T) {
 		}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), common.HexToAddress("0x000"), abi.ABI{})
+			&txService, owner, logrus.New(), common.HexToAddress("0x000"), abi.ABI{}, 0)
 
 		_, err = contract.GetBalance(ctx)
 		if err == nil {
@@ -89,7 +89,7 @@ func TestGetBalance(t *testing.T) {
 		}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), common.HexToAddress("0x000"), grydContractABI)
+			&txService, owner, logrus.New(), common.HexToAddress("0x000"), grydContractABI, 0)
 
 		_, err := contract.GetBalance(ctx)
 		if err == nil {
@@ -128,7 +128,7 @@ func TestVerifyEvent(t *testing.T) {
 			}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), grydAddress, grydContractABI)
+			&txService, owner, logrus.New(), grydAddress, grydContractABI, 0)
 
 		_, err := contract.VerifyEvent(ctx, txHash.String())
 		if err != nil {
@@ -155,7 +155,7 @@ func TestVerifyEvent(t *testing.T) {
 			}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), grydAddress, grydContractABI)
+			&txService, owner, logrus.New(), grydAddress, grydContractABI, 0)
 
 		_, err := contract.VerifyEvent(ctx, txHash.String())
 		if err == nil {
@@ -163,6 +163,105 @@ func TestVerifyEvent(t *testing.T) {
 		}
 	})
 
+	t.Run("multiple logs", func(t *testing.T) {
+		t.Parallel()
+
+		topic := grydContractABI.Events["InsertDataSuccess"].ID
+		users := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+
+		var logs []*types.Log
+		// logs of other contracts, like a token transfer paying for the batch, are skipped
+		logs = append(logs, &types.Log{Topics: []common.Hash{common.HexToHash("0x1234")}, Address: common.HexToAddress("0x03")})
+		for _, user := range users {
+			data, err := grydContractABI.Events["InsertDataSuccess"].Inputs.NonIndexed().Pack(user, QueryTypeCreate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logs = append(logs, &types.Log{Topics: []common.Hash{topic}, Address: grydAddress, Data: data})
+		}
+
+		txService := txMock.New(
+			txMock.WithWaitForReceiptFunc(func(ctx context.Context, trHash common.Hash) (receipt *types.Receipt, err error) {
+				return &types.Receipt{Status: 1, Logs: logs}, nil
+			}))
+
+		events, err := NewContract(&txService, owner, logrus.New(), grydAddress, grydContractABI, 0).VerifyEvent(ctx, txHash.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 2 || events[0].User != users[0] || events[1].User != users[1] {
+			t.Fatalf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("reverted", func(t *testing.T) {
+		t.Parallel()
+
+		txService := txMock.New(
+			txMock.WithWaitForReceiptFunc(func(ctx context.Context, trHash common.Hash) (receipt *types.Receipt, err error) {
+				return &types.Receipt{
+					Status: 0,
+					Logs: []*types.Log{
+						{Topics: []common.Hash{
+							grydContractABI.Events["InsertDataSuccess"].ID},
+							Address: grydAddress,
+						}},
+				}, nil
+			}))
+
+		_, err := NewContract(&txService, owner, logrus.New(), grydAddress, grydContractABI, 0).VerifyEvent(ctx, txHash.String())
+		if !errors.Is(err, transaction.ErrTransactionReverted) {
+			t.Fatalf("expected reverted, got %v", err)
+		}
+	})
+
+	t.Run("confirmations", func(t *testing.T) {
+		t.Parallel()
+
+		header := &types.Header{Number: big.NewInt(100)}
+
+		newTxService := func(head uint64, canonical *types.Header) transaction.Service {
+			return txMock.New(
+				txMock.WithWaitForReceiptFunc(func(ctx context.Context, trHash common.Hash) (receipt *types.Receipt, err error) {
+					return &types.Receipt{
+						Status: 1,
+						Logs: []*types.Log{
+							{Topics: []common.Hash{
+								grydContractABI.Events["InsertDataSuccess"].ID},
+								Address: grydAddress,
+							}},
+						BlockNumber: big.NewInt(100),
+						BlockHash:   header.Hash(),
+					}, nil
+				}),
+				txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+					return head, nil
+				}),
+				txMock.WithHeaderByNumberFunc(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+					return canonical, nil
+				}))
+		}
+
+		txService := newTxService(112, header)
+		_, err := NewContract(&txService, owner, logrus.New(), grydAddress, grydContractABI, 12).VerifyEvent(ctx, txHash.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		txService = newTxService(105, header)
+		_, err = NewContract(&txService, owner, logrus.New(), grydAddress, grydContractABI, 12).VerifyEvent(ctx, txHash.String())
+		if !errors.Is(err, ErrEventNotConfirmed) {
+			t.Fatalf("expected not confirmed, got %v", err)
+		}
+
+		txService = newTxService(112, &types.Header{Number: big.NewInt(100), Extra: []byte("fork")})
+		_, err = NewContract(&txService, owner, logrus.New(), grydAddress, grydContractABI, 12).VerifyEvent(ctx, txHash.String())
+		if !errors.Is(err, ErrBlockNotCanonical) {
+			t.Fatalf("expected not canonical, got %v", err)
+		}
+	})
+
 	t.Run("with incorrect receipt", func(t *testing.T) {
 		t.Parallel()
 
@@ -172,7 +271,7 @@ func TestVerifyEvent(t *testing.T) {
 			}))
 
 		contract := NewContract(
-			&txService, owner, logrus.New(), grydAddress, abi.ABI{})
+			&txService, owner, logrus.New(), grydAddress, abi.ABI{}, 0)
 
 		_, err := contract.VerifyEvent(ctx, txHash.String())
 		if err == nil {
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultIndexerPollInterval = 15 * time.Second
+	defaultIndexerBatchSize    = 1000
+)
+
+// IndexerOptions configures the indexer, blocks are only indexed once they are Confirmations blocks deep
+// and at most BatchSize blocks are filtered per request
+type IndexerOptions struct {
+	StartBlock    uint64
+	Confirmations uint64
+	PollInterval  time.Duration
+	BatchSize     uint64
+}
+
+// Indexer polls the InsertDataSuccess logs of the gryd contract and stores them in postgres, it resumes
+// from the last indexed block after a restart
+type Indexer struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	logger          *logrus.Logger
+	txService       transaction.Service
+	dbService       DBService
+	contractAddress common.Address
+	contractABI     abi.ABI
+	topic           common.Hash
+	options         IndexerOptions
+}
+
+func NewIndexer(txService *transaction.Service, dbService DBService, logger *logrus.Logger, grydAddress common.Address, grydABI abi.ABI, options IndexerOptions) *Indexer {
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultIndexerPollInterval
+	}
+	if options.BatchSize == 0 {
+		options.BatchSize = defaultIndexerBatchSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Indexer{
+		ctx:             ctx,
+		cancel:          cancel,
+		logger:          logger,
+		txService:       *txService,
+		dbService:       dbService,
+		contractAddress: grydAddress,
+		contractABI:     grydABI,
+		topic:           grydABI.Events["InsertDataSuccess"].ID,
+		options:         options,
+	}
+}
+
+// Start runs the indexer in the background until Close is called
+func (i *Indexer) Start() {
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+
+		for {
+			caughtUp, err := i.index(i.ctx)
+			if err != nil {
+				i.logger.Error("unable to index events: ", err)
+			}
+
+			// keep going without waiting while there are confirmed blocks left to index
+			if err == nil && !caughtUp {
+				if i.ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+
+			select {
+			case <-i.ctx.Done():
+				return
+			case <-time.After(i.options.PollInterval):
+			}
+		}
+	}()
+}
+
+func (i *Indexer) Close() error {
+	i.cancel()
+	i.wg.Wait()
+	return nil
+}
+
+// index stores the events of the next batch of confirmed blocks and reports whether it reached the last confirmed block
+func (i *Indexer) index(ctx context.Context) (bool, error) {
+	head, err := i.txService.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to get block number: %w", err)
+	}
+
+	if head < i.options.Confirmations {
+		return true, nil
+	}
+	confirmed := head - i.options.Confirmations
+
+	contract := i.contractKey()
+
+	lastBlock, err := i.dbService.LastIndexedBlock(ctx, contract)
+	if err != nil {
+		return false, err
+	}
+
+	from := lastBlock + 1
+	if from < i.options.StartBlock {
+		from = i.options.StartBlock
+	}
+
+	if from > confirmed {
+		return true, nil
+	}
+
+	to := from + i.options.BatchSize - 1
+	if to > confirmed {
+		to = confirmed
+	}
+
+	logs, err := i.txService.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{i.contractAddress},
+		Topics:    [][]common.Hash{{i.topic}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	events := make([]DTOEvent, 0, len(*logs))
+	for _, log := range *logs {
+		if log.Removed {
+			continue
+		}
+
+		var event EventInsertDataSuccess
+		err = transaction.ParseEvent(&i.contractABI, "InsertDataSuccess", &event, log)
+		if err != nil {
+			// a malformed log must not block the indexer, it is skipped like any other unknown event
+			i.logger.Error("unable to parse event of tx "+log.TxHash.Hex()+": ", err)
+			continue
+		}
+
+		events = append(events, DTOEvent{
+			TxHash:      log.TxHash.Hex(),
+			LogIndex:    log.Index,
+			BlockNumber: log.BlockNumber,
+			BlockHash:   log.BlockHash.Hex(),
+			Wallet:      event.User.Hex(),
+			QueryType:   event.QueryType,
+		})
+	}
+
+	err = i.dbService.SaveEvents(ctx, contract, events, to)
+	if err != nil {
+		return false, err
+	}
+
+	i.logger.Debug(fmt.Sprintf("indexed %d events from block %d to %d", len(events), from, to))
+
+	return to == confirmed, nil
+}
+
+// contractKey identifies the contract in the indexer table, pointing the node at a new contract starts a new index
+func (i *Indexer) contractKey() string {
+	return strings.ToLower(i.contractAddress.Hex())
+}
+
+// IndexedContract verifies events against the events stored by the indexer instead of fetching receipts live
+type IndexedContract struct {
+	GRYDContract
+	dbService DBService
+}
+
+func NewIndexedContract(contract GRYDContract, dbService DBService) GRYDContract {
+	return &IndexedContract{
+		GRYDContract: contract,
+		dbService:    dbService,
+	}
+}
+
+func (s *IndexedContract) VerifyEvent(ctx context.Context, hashTx string) ([]EventInsertDataSuccess, error) {
+	indexed, err := s.dbService.GetEventsByTxHash(ctx, hashTx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the indexed events of tx hash: %s with error: %w", hashTx, err)
+	}
+
+	events := make([]EventInsertDataSuccess, 0, len(indexed))
+	for _, event := range indexed {
+		events = append(events, EventInsertDataSuccess{
+			User:      common.HexToAddress(event.Wallet),
+			QueryType: event.QueryType,
+		})
+	}
+
+	return events, nil
+}
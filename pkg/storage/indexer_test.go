@@ -0,0 +1,172 @@
+package storage_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gryd-database/platform-poc/configuration"
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/storage/dbMock"
+	"github.com/gryd-database/platform-poc/pkg/transaction/txMock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestIndexer(t *testing.T) {
+	config, err := configuration.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grydAddress := common.HexToAddress(config.GRYDContract.Address)
+	grydABI := parseABI(t, config.GRYDContract.ABI)
+	user := common.HexToAddress("0xD07708ad91fbE34329507E2adABfb31534dD3efd")
+	txHash := common.HexToHash("0xcb0caeff88b8bda3656396b19b808cd8b35c0054e96553852441ea2c3f5f4d26")
+
+	data, err := grydABI.Events["InsertDataSuccess"].Inputs.NonIndexed().Pack(user, storage.QueryTypeCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		txService := txMock.New(
+			txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+				return 120, nil
+			}),
+			txMock.WithFilterLogsFunc(func(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error) {
+				if query.FromBlock.Uint64() != 101 || query.ToBlock.Uint64() != 110 {
+					return nil, fmt.Errorf("unexpected range: %d-%d", query.FromBlock, query.ToBlock)
+				}
+				return &[]types.Log{{
+					Address:     grydAddress,
+					Topics:      []common.Hash{grydABI.Events["InsertDataSuccess"].ID},
+					Data:        data,
+					BlockNumber: 105,
+					TxHash:      txHash,
+					Index:       2,
+				}}, nil
+			}))
+
+		saved := make(chan []storage.DTOEvent, 1)
+		dbService := dbMock.New(
+			dbMock.WithLastIndexedBlock(func(ctx context.Context, contract string) (uint64, error) {
+				return 100, nil
+			}),
+			dbMock.WithSaveEvents(func(ctx context.Context, contract string, events []storage.DTOEvent, lastBlock uint64) error {
+				if lastBlock != 110 {
+					return fmt.Errorf("unexpected last block: %d", lastBlock)
+				}
+				saved <- events
+				return nil
+			}))
+
+		indexer := storage.NewIndexer(&txService, dbService, logrus.New(), grydAddress, grydABI, storage.IndexerOptions{
+			Confirmations: 10,
+			BatchSize:     10,
+		})
+		indexer.Start()
+		defer indexer.Close()
+
+		events := <-saved
+		if len(events) != 1 || events[0].Wallet != user.Hex() || events[0].QueryType != storage.QueryTypeCreate || events[0].LogIndex != 2 {
+			t.Fatalf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("waits for confirmations", func(t *testing.T) {
+		t.Parallel()
+
+		txService := txMock.New(
+			txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+				return 105, nil
+			}),
+			txMock.WithFilterLogsFunc(func(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error) {
+				return nil, errors.New("unconfirmed blocks must not be filtered")
+			}))
+
+		polled := make(chan struct{}, 1)
+		dbService := dbMock.New(
+			dbMock.WithLastIndexedBlock(func(ctx context.Context, contract string) (uint64, error) {
+				select {
+				case polled <- struct{}{}:
+				default:
+				}
+				return 100, nil
+			}),
+			dbMock.WithSaveEvents(func(ctx context.Context, contract string, events []storage.DTOEvent, lastBlock uint64) error {
+				return errors.New("nothing must be saved")
+			}))
+
+		indexer := storage.NewIndexer(&txService, dbService, logrus.New(), grydAddress, grydABI, storage.IndexerOptions{
+			Confirmations: 10,
+		})
+		indexer.Start()
+		defer indexer.Close()
+
+		<-polled
+	})
+}
+
+func TestIndexedContractVerifyEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	user := "0xD07708ad91fbE34329507E2adABfb31534dD3efd"
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(dbMock.WithGetEventsByTxHash(func(ctx context.Context, txHash string) ([]storage.DTOEvent, error) {
+			return []storage.DTOEvent{{TxHash: txHash, Wallet: user, QueryType: storage.QueryTypeCreate}}, nil
+		}))
+
+		events, err := storage.NewIndexedContract(nil, dbService).VerifyEvent(ctx, "0x01")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 1 || events[0].User != common.HexToAddress(user) || events[0].QueryType != storage.QueryTypeCreate {
+			t.Fatalf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("not indexed", func(t *testing.T) {
+		t.Parallel()
+
+		dbService := dbMock.New(dbMock.WithGetEventsByTxHash(func(ctx context.Context, txHash string) ([]storage.DTOEvent, error) {
+			return nil, storage.ErrEventNotIndexed
+		}))
+
+		_, err := storage.NewIndexedContract(nil, dbService).VerifyEvent(ctx, "0x01")
+		if !errors.Is(err, storage.ErrEventNotIndexed) {
+			t.Fatalf("expected not indexed, got %v", err)
+		}
+	})
+}
+
+func parseABI(t *testing.T, jsonABI interface{}) abi.ABI {
+	t.Helper()
+
+	marshaled, err := json.Marshal(jsonABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(string(marshaled)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
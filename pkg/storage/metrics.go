@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gryd-database/platform-poc/pkg/storage")
+
+var (
+	rowsIngested = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gryd",
+		Subsystem: "storage",
+		Name:      "rows_ingested_total",
+		Help:      "Number of records stored in orbitdb by data type.",
+	}, []string{"data_type"})
+
+	odbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gryd",
+		Subsystem: "orbitdb",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of the orbitdb document store operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+)
+
+// startODB starts a span for an orbitdb operation, the returned func ends it and records the latency of the operation
+func startODB(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "orbitdb."+operation)
+
+	return ctx, func(err error) {
+		odbDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		endSpan(span, err)
+	}
+}
+
+// endSpan ends the span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -6,10 +6,16 @@ import (
 )
 
 type storageMock struct {
-	addRecord             func(ctx context.Context, storage *[]storage.InputData) error
-	ledger                func(ctx context.Context, wallet, datasetKey string) error
-	getWalletByDatasetKey func(ctx context.Context, key string) (*storage.Ledger, error)
-	getRecordByID         func(ctx context.Context, id string) (*storage.InputData, error)
+	addRecord              func(ctx context.Context, storage *[]storage.InputData) error
+	ledger                 func(ctx context.Context, wallet, datasetKey string) error
+	getWalletByDatasetKey  func(ctx context.Context, key string) (*storage.Ledger, error)
+	getRecordByID          func(ctx context.Context, id string) (*storage.InputData, error)
+	getRecordsByDatasetKey func(ctx context.Context, datasetKey, cursor string, limit int) (*storage.DatasetPage, error)
+	queryRecords           func(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error)
+	updateRecord           func(ctx context.Context, record *storage.InputData) error
+	deleteRecord           func(ctx context.Context, id string) error
+	replaceDataset         func(ctx context.Context, datasetKey string, records *[]storage.InputData) error
+	deleteDataset          func(ctx context.Context, datasetKey string) (int, error)
 }
 
 func (s *storageMock) AddRecord(ctx context.Context, storage *[]storage.InputData) error {
@@ -28,6 +34,30 @@ func (s *storageMock) GetRecordByID(ctx context.Context, id string) (*storage.In
 	return s.getRecordByID(ctx, id)
 }
 
+func (s *storageMock) GetRecordsByDatasetKey(ctx context.Context, datasetKey, cursor string, limit int) (*storage.DatasetPage, error) {
+	return s.getRecordsByDatasetKey(ctx, datasetKey, cursor, limit)
+}
+
+func (s *storageMock) QueryRecords(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error) {
+	return s.queryRecords(ctx, query)
+}
+
+func (s *storageMock) UpdateRecord(ctx context.Context, record *storage.InputData) error {
+	return s.updateRecord(ctx, record)
+}
+
+func (s *storageMock) DeleteRecord(ctx context.Context, id string) error {
+	return s.deleteRecord(ctx, id)
+}
+
+func (s *storageMock) ReplaceDataset(ctx context.Context, datasetKey string, records *[]storage.InputData) error {
+	return s.replaceDataset(ctx, datasetKey, records)
+}
+
+func (s *storageMock) DeleteDataset(ctx context.Context, datasetKey string) (int, error) {
+	return s.deleteDataset(ctx, datasetKey)
+}
+
 // Option is an option passed to New
 type Option func(mock *storageMock)
 
@@ -53,3 +83,51 @@ func WithLedger(f func(ctx context.Context, wallet, datasetKey string) error) Op
 		mock.ledger = f
 	}
 }
+
+func WithGetRecordByID(f func(ctx context.Context, id string) (*storage.InputData, error)) Option {
+	return func(mock *storageMock) {
+		mock.getRecordByID = f
+	}
+}
+
+func WithGetWalletByDatasetKey(f func(ctx context.Context, key string) (*storage.Ledger, error)) Option {
+	return func(mock *storageMock) {
+		mock.getWalletByDatasetKey = f
+	}
+}
+
+func WithGetRecordsByDatasetKey(f func(ctx context.Context, datasetKey, cursor string, limit int) (*storage.DatasetPage, error)) Option {
+	return func(mock *storageMock) {
+		mock.getRecordsByDatasetKey = f
+	}
+}
+
+func WithQueryRecords(f func(ctx context.Context, query *storage.RecordQuery) (*storage.QueryResult, error)) Option {
+	return func(mock *storageMock) {
+		mock.queryRecords = f
+	}
+}
+
+func WithUpdateRecord(f func(ctx context.Context, record *storage.InputData) error) Option {
+	return func(mock *storageMock) {
+		mock.updateRecord = f
+	}
+}
+
+func WithDeleteRecord(f func(ctx context.Context, id string) error) Option {
+	return func(mock *storageMock) {
+		mock.deleteRecord = f
+	}
+}
+
+func WithReplaceDataset(f func(ctx context.Context, datasetKey string, records *[]storage.InputData) error) Option {
+	return func(mock *storageMock) {
+		mock.replaceDataset = f
+	}
+}
+
+func WithDeleteDataset(f func(ctx context.Context, datasetKey string) (int, error)) Option {
+	return func(mock *storageMock) {
+		mock.deleteDataset = f
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidSortField = errors.New("invalid sort field")
+	ErrQueryTooBroad    = errors.New("query matches too many records")
+)
+
+// maxQueryMatches bounds the records a query collects before they are sorted and paged, broader queries are rejected
+const maxQueryMatches = 10000
+
+// recordSortFields maps the accepted sort fields to the comparison of the matching InputData values
+var recordSortFields = map[string]func(a, b *InputData) bool{
+	"id":       func(a, b *InputData) bool { return a.ID < b.ID },
+	"dataset":  func(a, b *InputData) bool { return a.Dataset < b.Dataset },
+	"dataType": func(a, b *InputData) bool { return a.DataType < b.DataType },
+	"date":     compareDates,
+}
+
+// RecordQuery filters the records of the docstore, zero values are not applied as filters except for DatasetKeys:
+// only records of the listed datasets are queried, so an empty list matches nothing
+type RecordQuery struct {
+	DatasetKeys []string
+	Dataset     string
+	DataType    string
+	From        time.Time
+	To          time.Time
+	SortBy      string
+	Desc        bool
+	Limit       int
+	Offset      int
+}
+
+// QueryResult holds the requested page of records and the total number of matching records
+type QueryResult struct {
+	Records []InputData `json:"records"`
+	Total   int         `json:"total"`
+}
+
+// Matches reports whether the record satisfies every filter of the query, records with an
+// unparseable date never match a date range
+func (q *RecordQuery) Matches(record *InputData) bool {
+	if len(q.Dataset) > 0 && record.Dataset != q.Dataset {
+		return false
+	}
+
+	if len(q.DataType) > 0 && record.DataType != q.DataType {
+		return false
+	}
+
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+
+	date, err := time.Parse(time.RFC3339, record.Date)
+	if err != nil {
+		return false
+	}
+
+	if !q.From.IsZero() && date.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && date.After(q.To) {
+		return false
+	}
+
+	return true
+}
+
+// compareDates orders records chronologically, falling back to the raw value when a date cannot be parsed
+func compareDates(a, b *InputData) bool {
+	dateA, errA := time.Parse(time.RFC3339, a.Date)
+	dateB, errB := time.Parse(time.RFC3339, b.Date)
+	if errA != nil || errB != nil {
+		return a.Date < b.Date
+	}
+
+	return dateA.Before(dateB)
+}
+
+// QueryRecords returns the requested page of the records matching the query, sorted by the requested field
+func (s *Storage) QueryRecords(ctx context.Context, query *RecordQuery) (*QueryResult, error) {
+	sortBy := query.SortBy
+	if len(sortBy) == 0 {
+		sortBy = "date"
+	}
+
+	less, ok := recordSortFields[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSortField, sortBy)
+	}
+
+	datasetKeys := make(map[string]bool, len(query.DatasetKeys))
+	for _, datasetKey := range query.DatasetKeys {
+		datasetKeys[datasetKey] = true
+	}
+
+	matches := 0
+	odbCtx, end := startODB(ctx, "query")
+	docs, err := s.odbStore.Query(odbCtx, func(doc interface{}) (bool, error) {
+		entity, ok := doc.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+
+		datasetKey, _ := entity["datasetKey"].(string)
+		if !datasetKeys[datasetKey] {
+			return false, nil
+		}
+
+		var record InputData
+		if err := mapstructure.Decode(entity, &record); err != nil {
+			return false, nil
+		}
+
+		if !query.Matches(&record) {
+			return false, nil
+		}
+
+		// the docstore stops the scan on the first filter error
+		matches++
+		if matches > maxQueryMatches {
+			return false, ErrQueryTooBroad
+		}
+		return true, nil
+	})
+	end(err)
+	if err != nil {
+		if errors.Is(err, ErrQueryTooBroad) {
+			return nil, fmt.Errorf("%w: more than %d records", ErrQueryTooBroad, maxQueryMatches)
+		}
+		return nil, fmt.Errorf("unable to query records: %w", err)
+	}
+
+	records, err := s.decodeRecords(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if query.Desc {
+			return less(&records[j], &records[i])
+		}
+		return less(&records[i], &records[j])
+	})
+
+	result := &QueryResult{Total: len(records), Records: []InputData{}}
+	if query.Offset >= len(records) {
+		return result, nil
+	}
+
+	records = records[query.Offset:]
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[:query.Limit]
+	}
+	result.Records = records
+
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordQueryMatches(t *testing.T) {
+	t.Parallel()
+
+	record := &InputData{
+		Dataset:  "sensor1",
+		DataType: "Temperature",
+		Date:     "2023-07-10T06:47:17+00:00",
+	}
+
+	tests := []struct {
+		name  string
+		query RecordQuery
+		want  bool
+	}{
+		{name: "empty query", query: RecordQuery{}, want: true},
+		{name: "dataset", query: RecordQuery{Dataset: "sensor1"}, want: true},
+		{name: "other dataset", query: RecordQuery{Dataset: "sensor2"}, want: false},
+		{name: "other data type", query: RecordQuery{DataType: "Humidity"}, want: false},
+		{
+			name:  "within range",
+			query: RecordQuery{From: time.Date(2023, 7, 10, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 7, 11, 0, 0, 0, 0, time.UTC)},
+			want:  true,
+		},
+		{
+			name:  "before range",
+			query: RecordQuery{From: time.Date(2023, 7, 11, 0, 0, 0, 0, time.UTC)},
+			want:  false,
+		},
+		{
+			name:  "after range",
+			query: RecordQuery{To: time.Date(2023, 7, 9, 0, 0, 0, 0, time.UTC)},
+			want:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.query.Matches(record); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("unparseable date", func(t *testing.T) {
+		t.Parallel()
+
+		query := RecordQuery{From: time.Date(2023, 7, 10, 0, 0, 0, 0, time.UTC)}
+		if query.Matches(&InputData{Date: "yesterday"}) {
+			t.Fatal("expected record with invalid date to not match a date range")
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// QuotaLimits bounds the rows and bytes a single wallet can store, a limit of 0 is unlimited
+type QuotaLimits struct {
+	MaxRows  int64 `json:"maxRows"`
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+type DTOQuota struct {
+	Wallet      string    `json:"wallet"`
+	RowsStored  int64     `json:"rowsStored"`
+	BytesStored int64     `json:"bytesStored"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// RecordsSize returns the number of bytes the records take up against the quota
+func RecordsSize(records []InputData) int64 {
+	var size int64
+	for _, record := range records {
+		size += RecordSize(&record)
+	}
+
+	return size
+}
+
+// RecordSize returns the number of bytes of the user supplied fields of the record
+func RecordSize(record *InputData) int64 {
+	return int64(len(record.Dataset) + len(record.Date) + len(record.DataType) + len(record.Data))
+}
+
+func (s *Storage) GetQuota(ctx context.Context, wallet string) (*DTOQuota, error) {
+	sqls, args, err := QB.Select("wallet", "rowsStored", "bytesStored", "updatedAt").
+		From("quota").
+		Where("wallet = ?", wallet).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for get quota: %w", err)
+	}
+
+	var dtoQuota DTOQuota
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&dtoQuota.Wallet, &dtoQuota.RowsStored, &dtoQuota.BytesStored, &dtoQuota.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// wallets without uploads have not used any of their quota
+			return &DTOQuota{Wallet: wallet}, nil
+		}
+		return nil, fmt.Errorf("error executing query for get quota: %w", err)
+	}
+
+	return &dtoQuota, nil
+}
+
+// AdjustQuota adds the deltas to the usage of the wallet, positive deltas are rejected with
+// ErrQuotaExceeded when they would exceed the limits while negative deltas always apply
+func (s *Storage) AdjustQuota(ctx context.Context, wallet string, rows, bytes int64, limits QuotaLimits) (*DTOQuota, error) {
+	// the first upload of a wallet inserts the usage and is not covered by the conflict condition
+	rowsHeadroom, ok := quotaHeadroom(limits.MaxRows, rows)
+	if !ok {
+		return nil, ErrQuotaExceeded
+	}
+
+	bytesHeadroom, ok := quotaHeadroom(limits.MaxBytes, bytes)
+	if !ok {
+		return nil, ErrQuotaExceeded
+	}
+
+	sqls, args, err := QB.Insert("quota").
+		Columns("wallet", "rowsStored", "bytesStored").
+		Values(wallet, nonNegative(rows), nonNegative(bytes)).
+		Suffix(`ON CONFLICT (wallet) DO UPDATE SET
+			rowsStored = GREATEST(quota.rowsStored + ?, 0),
+			bytesStored = GREATEST(quota.bytesStored + ?, 0),
+			updatedAt = CURRENT_TIMESTAMP
+		WHERE quota.rowsStored <= ? AND quota.bytesStored <= ?
+		RETURNING wallet, rowsStored, bytesStored, updatedAt`,
+			rows, bytes, rowsHeadroom, bytesHeadroom).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for adjust quota: %w", err)
+	}
+
+	var dtoQuota DTOQuota
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&dtoQuota.Wallet, &dtoQuota.RowsStored, &dtoQuota.BytesStored, &dtoQuota.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrQuotaExceeded
+		}
+		return nil, fmt.Errorf("error executing query for adjust quota: %w", err)
+	}
+
+	return &dtoQuota, nil
+}
+
+// quotaHeadroom returns the highest usage that still leaves room for delta under the limit,
+// it reports false when the delta alone exceeds the limit
+func quotaHeadroom(limit, delta int64) (int64, bool) {
+	if limit <= 0 || delta <= 0 {
+		return math.MaxInt64, true
+	}
+
+	if delta > limit {
+		return 0, false
+	}
+
+	return limit - delta, true
+}
+
+func nonNegative(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// status of a dataset with regard to the tx that paid for it
+const (
+	// StorageStatusPending datasets are rechecked until their tx is final
+	StorageStatusPending = "pending"
+	// StorageStatusFinal datasets are paid by a tx buried under enough confirmations
+	StorageStatusFinal = "final"
+	// StorageStatusReorged datasets lost their tx to a reorg or a revert
+	StorageStatusReorged = "reorged"
+)
+
+const (
+	defaultReorgInterval  = time.Minute
+	defaultReorgBatchSize = 100
+	defaultReorgGrace     = 10 * time.Minute
+)
+
+// ReorgOptions configures the re-check of pending datasets, a tx missing for longer than Grace after the dataset
+// was created is considered dropped and the dataset is deleted when Rollback is set
+type ReorgOptions struct {
+	Confirmations uint64
+	Interval      time.Duration
+	BatchSize     int
+	Grace         time.Duration
+	Rollback      bool
+}
+
+// ListPending returns the oldest datasets whose tx is not final yet
+func (s *Storage) ListPending(ctx context.Context, limit int) ([]DTOStorage, error) {
+	sqls, args, err := storageSelect().
+		Where(sq.Eq{"status": StorageStatusPending}).
+		OrderBy("createdAt").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for list pending storage: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for list pending storage: %w", err)
+	}
+	defer rows.Close()
+
+	dtoStorages := []DTOStorage{}
+	for rows.Next() {
+		var dtoStorage DTOStorage
+		err := rows.Scan(&dtoStorage.ID, &dtoStorage.Wallet, &dtoStorage.TxHash, &dtoStorage.CreatedAt, &dtoStorage.DatasetKey, &dtoStorage.Status)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for list pending storage: %w", err)
+		}
+		dtoStorages = append(dtoStorages, dtoStorage)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for list pending storage: %w", rows.Err())
+	}
+
+	return dtoStorages, nil
+}
+
+func (s *Storage) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
+	sqls, args, err := QB.Update("storage").
+		Set("status", status).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query for set storage status: %w", err)
+	}
+
+	tag, err := s.pg.Exec(ctx, sqls, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query for set storage status: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrStorageNotFound
+	}
+
+	return nil
+}
+
+// ReorgWatcher rechecks the txs of pending datasets in the background, datasets are marked final once their
+// tx is confirmed on the canonical chain and reorged when it was dropped or reverted
+type ReorgWatcher struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	logger     *logrus.Logger
+	txService  transaction.Service
+	odbService OrbitService
+	dbService  DBService
+	options    ReorgOptions
+	now        func() time.Time
+}
+
+func NewReorgWatcher(txService *transaction.Service, odbService OrbitService, dbService DBService, logger *logrus.Logger, options ReorgOptions) *ReorgWatcher {
+	if options.Interval <= 0 {
+		options.Interval = defaultReorgInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultReorgBatchSize
+	}
+	if options.Grace <= 0 {
+		options.Grace = defaultReorgGrace
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ReorgWatcher{
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     logger,
+		txService:  *txService,
+		odbService: odbService,
+		dbService:  dbService,
+		options:    options,
+		now:        time.Now,
+	}
+}
+
+// Start runs the re-check in the background until Close is called
+func (w *ReorgWatcher) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		for {
+			err := w.check(w.ctx)
+			if err != nil {
+				w.logger.Error("unable to recheck pending datasets: ", err)
+			}
+
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(w.options.Interval):
+			}
+		}
+	}()
+}
+
+func (w *ReorgWatcher) Close() error {
+	w.cancel()
+	w.wg.Wait()
+	return nil
+}
+
+// check updates the status of a batch of pending datasets, a failure on one dataset does not stop the others
+func (w *ReorgWatcher) check(ctx context.Context) error {
+	pending, err := w.dbService.ListPending(ctx, w.options.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	head, err := w.txService.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get block number: %w", err)
+	}
+
+	for i := range pending {
+		dataset := &pending[i]
+
+		status, err := w.status(ctx, dataset, head)
+		if err != nil {
+			w.logger.Error("unable to recheck tx "+dataset.TxHash+": ", err)
+			continue
+		}
+
+		if status == StorageStatusPending {
+			continue
+		}
+
+		if status == StorageStatusReorged {
+			w.logger.Warn("tx " + dataset.TxHash + " of dataset " + dataset.DatasetKey + " was reorged out")
+
+			if w.options.Rollback {
+				err = w.rollback(ctx, dataset)
+				if err != nil {
+					w.logger.Error("unable to roll back dataset "+dataset.DatasetKey+": ", err)
+					continue
+				}
+			}
+		}
+
+		err = w.dbService.SetStatus(ctx, dataset.ID, status)
+		if err != nil {
+			w.logger.Error("unable to set status of dataset "+dataset.DatasetKey+": ", err)
+		}
+	}
+
+	return nil
+}
+
+// status returns the status of the dataset according to the receipt of its tx
+func (w *ReorgWatcher) status(ctx context.Context, dataset *DTOStorage, head uint64) (string, error) {
+	receipt, err := w.txService.WaitForReceipt(ctx, common.HexToHash(dataset.TxHash))
+	if errors.Is(err, ethereum.NotFound) {
+		// a reorged tx usually goes back to the mempool, it is only considered dropped once the grace period is over
+		if w.now().Sub(dataset.CreatedAt) < w.options.Grace {
+			return StorageStatusPending, nil
+		}
+		return StorageStatusReorged, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return StorageStatusReorged, nil
+	}
+
+	blockNumber := receipt.BlockNumber.Uint64()
+	if head < blockNumber || head-blockNumber < w.options.Confirmations {
+		return StorageStatusPending, nil
+	}
+
+	header, err := w.txService.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return "", fmt.Errorf("unable to get header of block %d: %w", blockNumber, err)
+	}
+
+	// the receipt belongs to a block that is being replaced, the next run sees the new one
+	if header.Hash() != receipt.BlockHash {
+		return StorageStatusPending, nil
+	}
+
+	return StorageStatusFinal, nil
+}
+
+// rollback deletes the records of the dataset and gives the quota they used back to the wallet. A dataset
+// changed by a later tx is kept, its records are no longer the ones paid for by the reorged tx.
+func (w *ReorgWatcher) rollback(ctx context.Context, dataset *DTOStorage) error {
+	tombstones, err := w.dbService.ListTombstonesByDatasetKey(ctx, dataset.DatasetKey)
+	if err != nil {
+		return err
+	}
+
+	if len(tombstones) > 0 {
+		w.logger.Warn("dataset " + dataset.DatasetKey + " was changed by tx " + tombstones[len(tombstones)-1].TxHash +
+			" after the reorged tx " + dataset.TxHash + ", its records are kept")
+		return nil
+	}
+
+	existing, err := w.odbService.GetRecordsByDatasetKey(ctx, dataset.DatasetKey, "", 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.odbService.DeleteDataset(ctx, dataset.DatasetKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.dbService.AdjustQuota(ctx, dataset.Wallet, -int64(len(existing.Records)), -RecordsSize(existing.Records), QuotaLimits{})
+	if err != nil {
+		w.logger.Error("unable to revert quota of wallet "+dataset.Wallet+": ", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package storage_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/storage/dbMock"
+	"github.com/gryd-database/platform-poc/pkg/storage/odbMock"
+	"github.com/gryd-database/platform-poc/pkg/transaction/txMock"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestReorgWatcher(t *testing.T) {
+	t.Parallel()
+
+	header := &types.Header{Number: big.NewInt(100)}
+	finalTx := common.HexToHash("0x01")
+	droppedTx := common.HexToHash("0x02")
+	recentTx := common.HexToHash("0x03")
+	updatedTx := common.HexToHash("0x04")
+
+	pending := []storage.DTOStorage{
+		{ID: uuid.New(), TxHash: finalTx.Hex(), DatasetKey: "final", CreatedAt: time.Now().Add(-time.Hour)},
+		{ID: uuid.New(), TxHash: droppedTx.Hex(), DatasetKey: "dropped", CreatedAt: time.Now().Add(-time.Hour)},
+		{ID: uuid.New(), TxHash: recentTx.Hex(), DatasetKey: "recent", CreatedAt: time.Now()},
+		// replaced by an update paid for by another tx, the records of the update must survive the rollback
+		{ID: uuid.New(), TxHash: updatedTx.Hex(), DatasetKey: "updated", CreatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	txService := txMock.New(
+		txMock.WithWaitForReceiptFunc(func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			if txHash == finalTx {
+				return &types.Receipt{Status: 1, BlockNumber: big.NewInt(100), BlockHash: header.Hash()}, nil
+			}
+			return nil, ethereum.NotFound
+		}),
+		txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+			return 120, nil
+		}),
+		txMock.WithHeaderByNumberFunc(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return header, nil
+		}))
+
+	statuses := make(chan string, len(pending))
+	dbService := dbMock.New(
+		dbMock.WithListPending(func(ctx context.Context, limit int) ([]storage.DTOStorage, error) {
+			return pending, nil
+		}),
+		dbMock.WithSetStatus(func(ctx context.Context, id uuid.UUID, status string) error {
+			for _, dataset := range pending {
+				if dataset.ID == id {
+					statuses <- dataset.DatasetKey + ":" + status
+				}
+			}
+			return nil
+		}),
+		dbMock.WithListTombstonesByDatasetKey(func(ctx context.Context, datasetKey string) ([]storage.DTOTombstone, error) {
+			if datasetKey == "updated" {
+				return []storage.DTOTombstone{{DatasetKey: datasetKey, TxHash: common.HexToHash("0x05").Hex(), QueryType: storage.QueryTypeUpdate}}, nil
+			}
+			return nil, nil
+		}),
+		dbMock.WithAdjustQuota(func(ctx context.Context, wallet string, rows, bytes int64, limits storage.QuotaLimits) (*storage.DTOQuota, error) {
+			if rows != -1 {
+				return nil, errors.New("unexpected rows")
+			}
+			return &storage.DTOQuota{}, nil
+		}))
+
+	deleted := make(chan string, len(pending))
+	odbService := odbMock.New(
+		odbMock.WithGetRecordsByDatasetKey(func(ctx context.Context, datasetKey, cursor string, limit int) (*storage.DatasetPage, error) {
+			return &storage.DatasetPage{Records: []storage.InputData{{ID: "a", DatasetKey: datasetKey}}}, nil
+		}),
+		odbMock.WithDeleteDataset(func(ctx context.Context, datasetKey string) (int, error) {
+			deleted <- datasetKey
+			return 1, nil
+		}))
+
+	watcher := storage.NewReorgWatcher(&txService, odbService, dbService, logrus.New(), storage.ReorgOptions{
+		Confirmations: 12,
+		Interval:      time.Hour,
+		Rollback:      true,
+	})
+	watcher.Start()
+	defer watcher.Close()
+
+	got := map[string]bool{<-statuses: true, <-statuses: true, <-statuses: true}
+	if !got["final:"+storage.StorageStatusFinal] || !got["dropped:"+storage.StorageStatusReorged] || !got["updated:"+storage.StorageStatusReorged] {
+		t.Fatalf("unexpected statuses: %v", got)
+	}
+
+	if key := <-deleted; key != "dropped" {
+		t.Fatalf("unexpected rollback of %s", key)
+	}
+
+	select {
+	case key := <-deleted:
+		t.Fatalf("unexpected rollback of %s", key)
+	default:
+	}
+
+	select {
+	case status := <-statuses:
+		t.Fatalf("recent dataset must stay pending, got %s", status)
+	default:
+	}
+}
@@ -8,14 +8,27 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
 )
 
+// ErrPartialMutation is returned when a change of a dataset failed after some of its records were already changed
+var ErrPartialMutation = errors.New("dataset partially changed")
+
 type OrbitService interface {
 	AddRecord(ctx context.Context, storage *[]InputData) error
 	Ledger(ctx context.Context, wallet, datasetKey string) error
 	GetWalletByDatasetKey(ctx context.Context, key string) (*Ledger, error)
 	GetRecordByID(ctx context.Context, id string) (*InputData, error)
+	GetRecordsByDatasetKey(ctx context.Context, datasetKey, cursor string, limit int) (*DatasetPage, error)
+	QueryRecords(ctx context.Context, query *RecordQuery) (*QueryResult, error)
+	UpdateRecord(ctx context.Context, record *InputData) error
+	DeleteRecord(ctx context.Context, id string) error
+	ReplaceDataset(ctx context.Context, datasetKey string, records *[]InputData) error
+	DeleteDataset(ctx context.Context, datasetKey string) (int, error)
 }
 
 type InputData struct {
@@ -27,6 +40,12 @@ type InputData struct {
 	Data       string `mapstructure:"data" json:"data"`
 }
 
+// DatasetPage holds a page of records sharing the same dataset key, NextCursor is empty on the last page
+type DatasetPage struct {
+	Records    []InputData `json:"records"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
 // Ledger holds the dataset key and the wallet that inserted the data
 type Ledger struct {
 	Key    string `mapstructure:"key" json:"-"`
@@ -52,7 +71,10 @@ func New(owner common.Address, logger *logrus.Logger, pool *pgxpool.Pool, store
 	return storage, storage
 }
 
-func (s *Storage) Ledger(ctx context.Context, wallet, datasetKey string) error {
+func (s *Storage) Ledger(ctx context.Context, wallet, datasetKey string) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.Ledger", trace.WithAttributes(attribute.String("dataset.key", datasetKey)))
+	defer func() { endSpan(span, err) }()
+
 	record := Ledger{
 		Key:    datasetKey,
 		Wallet: wallet,
@@ -63,33 +85,50 @@ func (s *Storage) Ledger(ctx context.Context, wallet, datasetKey string) error {
 		return fmt.Errorf("unable to add recrod to ledger: %w", err)
 	}
 
-	_, err = s.ledger.Put(ctx, ledger)
+	odbCtx, end := startODB(ctx, "put")
+	_, err = s.ledger.Put(odbCtx, ledger)
+	end(err)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Storage) AddRecord(ctx context.Context, storage *[]InputData) error {
-	for _, row := range *storage {
+func (s *Storage) AddRecord(ctx context.Context, storage *[]InputData) (err error) {
+	ctx, span := tracer.Start(ctx, "storage.AddRecord", trace.WithAttributes(attribute.Int("rows", len(*storage))))
+	defer func() { endSpan(span, err) }()
+
+	_, err = s.addRecords(ctx, *storage)
+	return err
+}
+
+// addRecords stores the records one by one and returns how many of them were stored
+func (s *Storage) addRecords(ctx context.Context, records []InputData) (int, error) {
+	for i, row := range records {
 		entity, err := structToMap(row)
 		if err != nil {
 			s.logger.Error("failed to encode struct into map: ", err)
-			return err
+			return i, err
 		}
 
-		_, err = s.odbStore.Put(ctx, entity)
+		odbCtx, end := startODB(ctx, "put")
+		_, err = s.odbStore.Put(odbCtx, entity)
+		end(err)
 		if err != nil {
 			s.logger.Error("failed to add data into odb: ", err)
-			return err
+			return i, err
 		}
+
+		rowsIngested.WithLabelValues(row.DataType).Inc()
 	}
 
-	return nil
+	return len(records), nil
 }
 
 func (s *Storage) GetRecordByID(ctx context.Context, id string) (*InputData, error) {
-	record, err := s.odbStore.Get(ctx, id, &iface.DocumentStoreGetOptions{CaseInsensitive: false})
+	odbCtx, end := startODB(ctx, "get")
+	record, err := s.odbStore.Get(odbCtx, id, &iface.DocumentStoreGetOptions{CaseInsensitive: false})
+	end(err)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +146,9 @@ func (s *Storage) GetRecordByID(ctx context.Context, id string) (*InputData, err
 }
 
 func (s *Storage) GetWalletByDatasetKey(ctx context.Context, key string) (*Ledger, error) {
-	record, err := s.ledger.Get(ctx, key, &iface.DocumentStoreGetOptions{CaseInsensitive: false})
+	odbCtx, end := startODB(ctx, "get")
+	record, err := s.ledger.Get(odbCtx, key, &iface.DocumentStoreGetOptions{CaseInsensitive: false})
+	end(err)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +165,141 @@ func (s *Storage) GetWalletByDatasetKey(ctx context.Context, key string) (*Ledge
 	return &data, nil
 }
 
+func (s *Storage) GetRecordsByDatasetKey(ctx context.Context, datasetKey, cursor string, limit int) (*DatasetPage, error) {
+	odbCtx, end := startODB(ctx, "query")
+	docs, err := s.odbStore.Query(odbCtx, func(doc interface{}) (bool, error) {
+		entity, ok := doc.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		return entity["datasetKey"] == datasetKey, nil
+	})
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query records of dataset: %w", err)
+	}
+
+	records, err := s.decodeRecords(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	// records are ordered by id so the id of the last returned record can be used as cursor
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	start := sort.Search(len(records), func(i int) bool {
+		return records[i].ID > cursor
+	})
+	records = records[start:]
+
+	page := &DatasetPage{Records: records}
+	if limit > 0 && len(records) > limit {
+		page.Records = records[:limit]
+		page.NextCursor = page.Records[limit-1].ID
+	}
+
+	return page, nil
+}
+
+// UpdateRecord overwrites the stored record with the same id
+func (s *Storage) UpdateRecord(ctx context.Context, record *InputData) error {
+	entity, err := structToMap(*record)
+	if err != nil {
+		s.logger.Error("failed to encode struct into map: ", err)
+		return err
+	}
+
+	odbCtx, end := startODB(ctx, "put")
+	_, err = s.odbStore.Put(odbCtx, entity)
+	end(err)
+	if err != nil {
+		return fmt.Errorf("unable to update record %s: %w", record.ID, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteRecord(ctx context.Context, id string) error {
+	odbCtx, end := startODB(ctx, "delete")
+	_, err := s.odbStore.Delete(odbCtx, id)
+	end(err)
+	if err != nil {
+		return fmt.Errorf("unable to delete record %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// ReplaceDataset stores the given records and deletes the previous records of the dataset afterwards. When the new
+// records cannot be stored the ones already stored are removed again so the dataset is unchanged, ErrPartialMutation
+// is returned when the dataset is left with a mix of previous and new records.
+func (s *Storage) ReplaceDataset(ctx context.Context, datasetKey string, records *[]InputData) error {
+	previous, err := s.GetRecordsByDatasetKey(ctx, datasetKey, "", 0)
+	if err != nil {
+		return err
+	}
+
+	added, err := s.addRecords(ctx, *records)
+	if err != nil {
+		if _, cleanupErr := s.deleteRecords(ctx, (*records)[:added]); cleanupErr != nil {
+			return fmt.Errorf("%w: %d new records left after %w, unable to remove them: %v", ErrPartialMutation, added, err, cleanupErr)
+		}
+		return err
+	}
+
+	deleted, err := s.deleteRecords(ctx, previous.Records)
+	if err != nil {
+		return fmt.Errorf("%w: %d of %d previous records deleted: %w", ErrPartialMutation, deleted, len(previous.Records), err)
+	}
+
+	return nil
+}
+
+// DeleteDataset deletes every record of the dataset and returns the number of deleted records,
+// the ledger entry is kept so the ownership of the dataset key stays verifiable
+func (s *Storage) DeleteDataset(ctx context.Context, datasetKey string) (int, error) {
+	page, err := s.GetRecordsByDatasetKey(ctx, datasetKey, "", 0)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.deleteRecords(ctx, page.Records)
+	if err != nil && deleted > 0 {
+		return deleted, fmt.Errorf("%w: %d of %d records deleted: %w", ErrPartialMutation, deleted, len(page.Records), err)
+	}
+
+	return deleted, err
+}
+
+// deleteRecords deletes the records one by one and returns how many of them were deleted
+func (s *Storage) deleteRecords(ctx context.Context, records []InputData) (int, error) {
+	for i, record := range records {
+		err := s.DeleteRecord(ctx, record.ID)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(records), nil
+}
+
+func (s *Storage) decodeRecords(docs []interface{}) ([]InputData, error) {
+	records := make([]InputData, 0, len(docs))
+	for _, doc := range docs {
+		var data InputData
+		err := mapstructure.Decode(doc, &data)
+		if err != nil {
+			s.logger.Error("failed to decode map into struct: ", err)
+			return nil, err
+		}
+		records = append(records, data)
+	}
+
+	return records, nil
+}
+
 func structToMap(v interface{}) (map[string]interface{}, error) {
 	vMap := &map[string]interface{}{}
 
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrChainNotSynced = errors.New("chain backend not synced")
+)
+
+// SyncedContract refuses to verify events while the chain backend is lagging, a lagging backend does not know
+// recent txs and would reject them as missing
+type SyncedContract struct {
+	GRYDContract
+	checker *transaction.SyncChecker
+}
+
+func NewSyncedContract(contract GRYDContract, checker *transaction.SyncChecker) GRYDContract {
+	return &SyncedContract{
+		GRYDContract: contract,
+		checker:      checker,
+	}
+}
+
+func (s *SyncedContract) VerifyEvent(ctx context.Context, hashTx string) ([]EventInsertDataSuccess, error) {
+	synced, blockTime, err := s.checker.Synced(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check chain sync: %w", err)
+	}
+
+	if !synced {
+		return nil, fmt.Errorf("%w: last block at %s", ErrChainNotSynced, blockTime.UTC().Format(time.RFC3339))
+	}
+
+	return s.GRYDContract.VerifyEvent(ctx, hashTx)
+}
@@ -0,0 +1,56 @@
+package storage_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gryd-database/platform-poc/pkg/storage"
+	"github.com/gryd-database/platform-poc/pkg/storage/grydContractMock"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/gryd-database/platform-poc/pkg/transaction/txMock"
+	"github.com/pkg/errors"
+)
+
+func TestSyncedContractVerifyEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	contract := grydContractMock.New(grydContractMock.WithVerifyEvent(func(ctx context.Context, hashTx string) ([]storage.EventInsertDataSuccess, error) {
+		return []storage.EventInsertDataSuccess{{QueryType: storage.QueryTypeCreate}}, nil
+	}))
+
+	checker := func(blockTime time.Time) *transaction.SyncChecker {
+		return transaction.NewSyncChecker(txMock.New(
+			txMock.WithBlockNumberFunc(func(ctx context.Context) (uint64, error) {
+				return 100, nil
+			}),
+			txMock.WithHeaderByNumberFunc(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+				return &types.Header{Number: number, Time: uint64(blockTime.Unix())}, nil
+			})), time.Minute)
+	}
+
+	t.Run("synced", func(t *testing.T) {
+		t.Parallel()
+
+		events, err := storage.NewSyncedContract(contract, checker(time.Now())).VerifyEvent(ctx, "0x01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("unexpected events: %+v", events)
+		}
+	})
+
+	t.Run("lagging", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := storage.NewSyncedContract(contract, checker(time.Now().Add(-time.Hour))).VerifyEvent(ctx, "0x01")
+		if !errors.Is(err, storage.ErrChainNotSynced) {
+			t.Fatalf("expected not synced, got %v", err)
+		}
+	})
+}
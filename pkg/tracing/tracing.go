@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gryd-database/platform-poc/configuration"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	defaultServiceName = "gryd-node"
+)
+
+// Init installs the global tracer provider exporting spans as configured, it returns nil when tracing is disabled
+// and the global no-op provider is kept. The provider must be shut down to flush the last spans.
+func Init(config *configuration.Config) (*sdktrace.TracerProvider, error) {
+	args := config.Tracing
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch args.Exporter {
+	case "":
+		return nil, nil
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(args.Endpoint)}
+		if args.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", args.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s trace exporter: %w", args.Exporter, err)
+	}
+
+	serviceName := args.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if args.SampleRatio > 0 && args.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(args.SampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
 )
 
 // Backend is the minimum of blockchain backend functions we need.
@@ -33,11 +34,17 @@ type Backend interface {
 	Close()
 }
 
+// ChainReader is the part of a backend needed to tell whether it is synced, the transaction service is one too.
+type ChainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
 // IsSynced will check if we are synced with the given blockchain backend. This
 // is true if the current wall clock is after the block time of last block
 // with the given maxDelay as the maximum duration we can be behind the block
 // time.
-func IsSynced(ctx context.Context, backend Backend, maxDelay time.Duration) (bool, time.Time, error) {
+func IsSynced(ctx context.Context, backend ChainReader, maxDelay time.Duration) (bool, time.Time, error) {
 	number, err := backend.BlockNumber(ctx)
 	if err != nil {
 		return false, time.Time{}, fmt.Errorf("cannot get block number :%w", err)
@@ -58,7 +65,7 @@ func IsSynced(ctx context.Context, backend Backend, maxDelay time.Duration) (boo
 // WaitSynced will wait until we are synced with the given blockchain backend,
 // with the given maxDelay duration as the maximum time we can be behind the
 // last block.
-func WaitSynced(ctx context.Context, backend Backend, maxDelay time.Duration) error {
+func WaitSynced(ctx context.Context, logger *logrus.Logger, backend ChainReader, maxDelay time.Duration) error {
 	for {
 		synced, blockTime, err := IsSynced(ctx, backend, maxDelay)
 		if err != nil {
@@ -69,7 +76,7 @@ func WaitSynced(ctx context.Context, backend Backend, maxDelay time.Duration) er
 			return nil
 		}
 
-		fmt.Println("waiting on sync, blockTime: ", blockTime)
+		logger.Info("waiting on sync, blockTime: ", blockTime)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
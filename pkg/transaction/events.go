@@ -53,3 +53,28 @@ func FindSingleEvent(abi *abi.ABI, receipt *types.Receipt, contractAddress commo
 	}
 	return ErrEventNotFound
 }
+
+// FindEvents will find all events of the given kind emitted by the contract, in log order.
+func FindEvents(receipt *types.Receipt, contractAddress common.Address, event abi.Event) ([]types.Log, error) {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, ErrTransactionReverted
+	}
+	var logs []types.Log
+	for _, log := range receipt.Logs {
+		if log.Address != contractAddress {
+			continue
+		}
+		if len(log.Topics) == 0 {
+			continue
+		}
+		if log.Topics[0] != event.ID {
+			continue
+		}
+
+		logs = append(logs, *log)
+	}
+	if len(logs) == 0 {
+		return nil, ErrEventNotFound
+	}
+	return logs, nil
+}
@@ -0,0 +1,15 @@
+package transaction
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// rpcDuration is the latency of every attempt of a call made through the wrapped backend
+var rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "gryd",
+	Subsystem: "rpc",
+	Name:      "call_duration_seconds",
+	Help:      "Latency of the chain rpc calls by method and outcome.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "outcome"})
@@ -0,0 +1,350 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrTransactionCancelled  = errors.New("transaction cancelled")
+	ErrTransactionReplaced   = errors.New("transaction replaced")
+	ErrTransactionNotPending = errors.New("transaction not pending")
+	ErrTransactionNotSent    = errors.New("transaction not sent")
+	ErrMonitorClosed         = errors.New("transaction monitor closed")
+)
+
+const (
+	defaultMonitorPollInterval = 10 * time.Second
+	defaultMonitorStuckAfter   = 5 * time.Minute
+	defaultMonitorResendBoost  = 20
+	// nodes only accept a replacement when its tip and fee cap are at least 10% above the replaced transaction
+	minReplacementBoost = 10
+	cancelGasLimit      = 21000
+)
+
+// MonitorOptions configures the transaction monitor, a pending transaction is stuck once it was not mined for
+// StuckAfter and is then replaced with its fees boosted by ResendBoost percent
+type MonitorOptions struct {
+	PollInterval time.Duration
+	StuckAfter   time.Duration
+	ResendBoost  int
+}
+
+// watch is a caller of WatchSentTransaction waiting for the transaction that uses its nonce to be mined
+type watch struct {
+	txHash   common.Hash
+	receiptC chan types.Receipt
+	errC     chan error
+}
+
+// sentTransaction is a pending transaction loaded from the store
+type sentTransaction struct {
+	txHash common.Hash
+	stored *StoredTransaction
+}
+
+// WatchSentTransaction returns channels receiving the receipt of the transaction once it is mined, watches follow the
+// nonce so a fee bumped replacement delivers its receipt while a cancellation delivers ErrTransactionCancelled
+func (t *TxService) WatchSentTransaction(txHash common.Hash) (<-chan types.Receipt, <-chan error, error) {
+	stored, err := t.store.Transaction(txHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := watch{
+		txHash:   txHash,
+		receiptC: make(chan types.Receipt, 1),
+		errC:     make(chan error, 1),
+	}
+
+	switch stored.Status {
+	case TransactionStatusPending:
+		t.watchLock.Lock()
+		defer t.watchLock.Unlock()
+
+		if t.ctx.Err() != nil {
+			return nil, nil, ErrMonitorClosed
+		}
+
+		t.watches[stored.Nonce] = append(t.watches[stored.Nonce], w)
+	case TransactionStatusReplaced:
+		w.errC <- ErrTransactionReplaced
+	case TransactionStatusFailed:
+		w.errC <- ErrTransactionNotSent
+	default:
+		receipt, err := t.backend.TransactionReceipt(t.ctx, txHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		w.receiptC <- *receipt
+	}
+
+	return w.receiptC, w.errC, nil
+}
+
+// ResendTransaction sends a pending transaction again unchanged, the signature is deterministic so it keeps its hash
+func (t *TxService) ResendTransaction(ctx context.Context, txHash common.Hash) error {
+	stored, err := t.store.Transaction(txHash)
+	if err != nil {
+		return err
+	}
+
+	if stored.Status != TransactionStatusPending {
+		return ErrTransactionNotPending
+	}
+
+	signedTx, err := t.signer.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   t.chainID,
+		Nonce:     stored.Nonce,
+		GasTipCap: stored.GasTipCap,
+		GasFeeCap: stored.GasFeeCap,
+		Gas:       stored.GasLimit,
+		To:        stored.To,
+		Value:     stored.Value,
+		Data:      stored.Data,
+	}), t.chainID)
+	if err != nil {
+		return err
+	}
+
+	if signedTx.Hash() != txHash {
+		return fmt.Errorf("resent transaction %s does not match %s", signedTx.Hash().Hex(), txHash.Hex())
+	}
+
+	return t.backend.SendTransaction(ctx, signedTx)
+}
+
+// CancelTransaction replaces a pending transaction with a zero value transfer to the sender using the same nonce
+func (t *TxService) CancelTransaction(ctx context.Context, originalTxHash common.Hash) (common.Hash, error) {
+	stored, err := t.store.Transaction(originalTxHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if stored.Status != TransactionStatusPending {
+		return common.Hash{}, ErrTransactionNotPending
+	}
+
+	return t.replace(ctx, originalTxHash, stored, t.options.ResendBoost, true)
+}
+
+// replace sends a transaction with the nonce of the stored one and fees boosted by boostPercent, or the suggested
+// fees when they are higher, a cancellation sends nothing to the sender instead of repeating the call
+func (t *TxService) replace(ctx context.Context, txHash common.Hash, stored *StoredTransaction, boostPercent int, cancel bool) (common.Hash, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if boostPercent < minReplacementBoost {
+		boostPercent = minReplacementBoost
+	}
+
+	gasFeeCap, gasTipCap, err := t.suggestedFeeAndTip(ctx, nil, 0)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if boosted := boost(stored.GasTipCap, boostPercent); boosted.Cmp(gasTipCap) > 0 {
+		gasTipCap = boosted
+	}
+	if boosted := boost(stored.GasFeeCap, boostPercent); boosted.Cmp(gasFeeCap) > 0 {
+		gasFeeCap = boosted
+	}
+	if gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasFeeCap = new(big.Int).Set(gasTipCap)
+	}
+
+	to, data, value, gasLimit, description := stored.To, stored.Data, stored.Value, stored.GasLimit, stored.Description
+	if cancel {
+		to, data, value, gasLimit, description = &t.sender, []byte{}, new(big.Int), cancelGasLimit, "cancel "+txHash.Hex()
+	}
+
+	signedTx, err := t.signAndSend(ctx, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   t.chainID,
+		Nonce:     stored.Nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}), description)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	err = t.storeTransaction(signedTx, boostPercent, description, cancel)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return signedTx.Hash(), nil
+}
+
+// monitor checks the pending transactions whenever a new block is seen until the service is closed
+func (t *TxService) monitor() {
+	defer t.wg.Done()
+
+	var lastBlock uint64
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(t.options.PollInterval):
+		}
+
+		block, err := t.backend.BlockNumber(t.ctx)
+		if err != nil {
+			t.logger.Error("transaction monitor unable to get block number: ", err)
+			continue
+		}
+
+		if block == lastBlock {
+			continue
+		}
+		lastBlock = block
+
+		err = t.checkPending(t.ctx)
+		if err != nil {
+			t.logger.Error("transaction monitor unable to check pending transactions: ", err)
+		}
+	}
+}
+
+// checkPending updates the pending transactions nonce by nonce, a failure on one nonce does not stop the others
+func (t *TxService) checkPending(ctx context.Context) error {
+	pending, err := t.store.PendingTransactions()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	confirmedNonce, err := t.backend.NonceAt(ctx, t.sender, nil)
+	if err != nil {
+		return err
+	}
+
+	nonces := make([]uint64, 0)
+	byNonce := make(map[uint64][]sentTransaction)
+	for _, txHash := range pending {
+		stored, err := t.store.Transaction(txHash)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := byNonce[stored.Nonce]; !ok {
+			nonces = append(nonces, stored.Nonce)
+		}
+		byNonce[stored.Nonce] = append(byNonce[stored.Nonce], sentTransaction{txHash: txHash, stored: stored})
+	}
+
+	for _, nonce := range nonces {
+		err = t.checkNonce(ctx, nonce, byNonce[nonce], confirmedNonce)
+		if err != nil {
+			t.logger.Error(fmt.Sprintf("transaction monitor unable to check nonce %d: ", nonce), err)
+		}
+	}
+
+	return nil
+}
+
+// checkNonce looks for the mined transaction among the ones sharing the nonce, the others are replaced by it.
+// When none of them is mined yet the most recent one is replaced with higher fees once it is stuck.
+func (t *TxService) checkNonce(ctx context.Context, nonce uint64, txs []sentTransaction, confirmedNonce uint64) error {
+	for _, tx := range txs {
+		receipt, err := t.backend.TransactionReceipt(ctx, tx.txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = t.store.SetStatus(tx.txHash, receiptStatus(receipt), receiptFee(receipt, tx.stored))
+		if err != nil {
+			return err
+		}
+
+		err = t.setReplaced(txs, tx.txHash)
+		if err != nil {
+			return err
+		}
+
+		t.notify(nonce, tx, receipt)
+		return nil
+	}
+
+	if nonce < confirmedNonce {
+		// the nonce was used by a transaction this service did not send
+		err := t.setReplaced(txs, common.Hash{})
+		if err != nil {
+			return err
+		}
+
+		t.notify(nonce, sentTransaction{}, nil)
+		return nil
+	}
+
+	latest := txs[0]
+	for _, tx := range txs[1:] {
+		if tx.stored.Created > latest.stored.Created {
+			latest = tx
+		}
+	}
+
+	if time.Since(time.Unix(latest.stored.Created, 0)) < t.options.StuckAfter {
+		return nil
+	}
+
+	replacement, err := t.replace(ctx, latest.txHash, latest.stored, t.options.ResendBoost, latest.stored.Cancellation)
+	if err != nil {
+		return fmt.Errorf("unable to replace stuck transaction %s: %w", latest.txHash.Hex(), err)
+	}
+
+	t.logger.Info("replaced stuck transaction ", latest.txHash.Hex(), " with ", replacement.Hex())
+
+	return nil
+}
+
+// setReplaced marks every transaction but the mined one as replaced
+func (t *TxService) setReplaced(txs []sentTransaction, mined common.Hash) error {
+	for _, tx := range txs {
+		if tx.txHash == mined {
+			continue
+		}
+
+		err := t.store.SetStatus(tx.txHash, TransactionStatusReplaced, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify delivers the outcome of the nonce to its watches, mined is empty when the nonce was used by someone else
+func (t *TxService) notify(nonce uint64, mined sentTransaction, receipt *types.Receipt) {
+	cancelled := receipt == nil || mined.stored.Cancellation
+
+	t.watchLock.Lock()
+	defer t.watchLock.Unlock()
+
+	for _, w := range t.watches[nonce] {
+		if cancelled && w.txHash != mined.txHash {
+			w.errC <- ErrTransactionCancelled
+			continue
+		}
+		w.receiptC <- *receipt
+	}
+
+	delete(t.watches, nonce)
+}
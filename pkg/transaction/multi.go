@@ -0,0 +1,438 @@
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	_ Backend = (*MultiBackend)(nil)
+
+	ErrNoBackend = errors.New("no backend available")
+	ErrNoQuorum  = errors.New("backends disagree on the receipt")
+	// ErrQuorumTooLarge is returned when the quorum asks for more receipts than there are endpoints
+	ErrQuorumTooLarge = errors.New("quorum exceeds the number of endpoints")
+)
+
+// DefaultMaxSyncDelay is the age of the last block after which a backend is considered lagging
+const DefaultMaxSyncDelay = 5 * time.Minute
+
+const (
+	defaultHealthCheckInterval = 15 * time.Second
+	healthCheckTimeout         = 10 * time.Second
+	// the score of an endpoint moves by one per call and is bounded so a recovered endpoint is trusted again quickly
+	maxEndpointScore = 10
+	minEndpointScore = -10
+)
+
+// MultiBackendOptions configures the health checks of the endpoints, an endpoint whose last block is older than
+// MaxSyncDelay is unhealthy. Quorum is the number of endpoints that must return the same receipt, 0 or 1 trusts
+// a single endpoint.
+type MultiBackendOptions struct {
+	HealthCheckInterval time.Duration
+	MaxSyncDelay        time.Duration
+	Quorum              int
+}
+
+// Endpoint is a backend and the name it is logged with
+type Endpoint struct {
+	Name    string
+	Backend Backend
+}
+
+type endpoint struct {
+	name    string
+	backend Backend
+	healthy bool
+	score   int
+}
+
+// MultiBackend spreads the calls over several endpoints, healthy endpoints with the best score are called first
+// and the next one is tried when an endpoint cannot be reached
+type MultiBackend struct {
+	wg     sync.WaitGroup
+	lock   sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	logger    *logrus.Logger
+	endpoints []*endpoint
+	options   MultiBackendOptions
+}
+
+// NewMultiBackend creates the backend and starts the health checks of the endpoints, Close stops them and closes the endpoints.
+// It fails when the quorum could never be reached by the endpoints.
+func NewMultiBackend(logger *logrus.Logger, endpoints []Endpoint, options MultiBackendOptions) (*MultiBackend, error) {
+	if options.Quorum > len(endpoints) {
+		return nil, fmt.Errorf("%w: quorum %d with %d endpoints", ErrQuorumTooLarge, options.Quorum, len(endpoints))
+	}
+	if options.HealthCheckInterval <= 0 {
+		options.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if options.MaxSyncDelay <= 0 {
+		options.MaxSyncDelay = DefaultMaxSyncDelay
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &MultiBackend{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger,
+		options: options,
+	}
+
+	for _, e := range endpoints {
+		b.endpoints = append(b.endpoints, &endpoint{
+			name:    e.Name,
+			backend: e.Backend,
+			healthy: true,
+		})
+	}
+
+	b.wg.Add(1)
+	go b.healthCheck()
+
+	return b, nil
+}
+
+// healthCheck marks the endpoints that are not synced as unhealthy until the backend is closed
+func (b *MultiBackend) healthCheck() {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(b.options.HealthCheckInterval):
+		}
+
+		for _, e := range b.endpoints {
+			ctx, cancel := context.WithTimeout(b.ctx, healthCheckTimeout)
+			synced, _, err := IsSynced(ctx, e.backend, b.options.MaxSyncDelay)
+			cancel()
+
+			healthy := err == nil && synced
+
+			b.lock.Lock()
+			changed := e.healthy != healthy
+			e.healthy = healthy
+			b.lock.Unlock()
+
+			if changed && healthy {
+				b.logger.Info("backend endpoint ", e.name, " is healthy again")
+			}
+			if changed && !healthy {
+				b.logger.Warn("backend endpoint ", e.name, " is unhealthy: synced=", synced, " error=", err)
+			}
+		}
+	}
+}
+
+// ordered returns the endpoints in the order they are tried
+func (b *MultiBackend) ordered() []*endpoint {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	type candidate struct {
+		*endpoint
+		healthy bool
+		score   int
+	}
+
+	candidates := make([]candidate, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		candidates = append(candidates, candidate{endpoint: e, healthy: e.healthy, score: e.score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].healthy != candidates[j].healthy {
+			return candidates[i].healthy
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	ordered := make([]*endpoint, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c.endpoint)
+	}
+
+	return ordered
+}
+
+func (b *MultiBackend) record(e *endpoint, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if ok && e.score < maxEndpointScore {
+		e.score++
+	}
+	if !ok && e.score > minEndpointScore {
+		e.score--
+	}
+}
+
+// call runs fn against the endpoints in order until one of them answers
+func (b *MultiBackend) call(ctx context.Context, fn func(backend Backend) error) error {
+	err := ErrNoBackend
+	for _, e := range b.ordered() {
+		err = fn(e.backend)
+		if err == nil || answered(err) {
+			b.record(e, true)
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		b.record(e, false)
+		b.logger.Warn("backend endpoint ", e.name, " failed, trying the next one: ", err)
+	}
+
+	return err
+}
+
+// answered reports whether the error is an answer of the endpoint rather than a failure to reach it,
+// such errors are the same on every endpoint while a rate limited endpoint is worth failing over
+func answered(err error) bool {
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && !Retryable(err)
+}
+
+func (b *MultiBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if b.options.Quorum > 1 {
+		return b.quorumReceipt(ctx, txHash)
+	}
+
+	var receipt *types.Receipt
+	err := b.call(ctx, func(backend Backend) error {
+		var err error
+		receipt, err = backend.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+// quorumReceipt returns the receipt once Quorum endpoints returned the same one, a single endpoint cannot forge
+// the logs of a receipt this way
+func (b *MultiBackend) quorumReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	type answer struct {
+		receipt *types.Receipt
+		count   int
+	}
+
+	answers := make(map[common.Hash]*answer)
+	notFound := 0
+	err := ErrNoBackend
+
+	for _, e := range b.ordered() {
+		var receipt *types.Receipt
+		receipt, err = e.backend.TransactionReceipt(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			b.record(e, true)
+			notFound++
+			if notFound >= b.options.Quorum {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			b.record(e, answered(err))
+			b.logger.Warn("backend endpoint ", e.name, " failed to return receipt: ", err)
+			continue
+		}
+
+		b.record(e, true)
+
+		key := receiptKey(receipt)
+		if _, ok := answers[key]; !ok {
+			answers[key] = &answer{receipt: receipt}
+		}
+		answers[key].count++
+
+		if answers[key].count >= b.options.Quorum {
+			return answers[key].receipt, nil
+		}
+	}
+
+	if len(answers) > 1 {
+		return nil, ErrNoQuorum
+	}
+
+	answeredCount := notFound
+	for _, a := range answers {
+		answeredCount += a.count
+	}
+	if answeredCount < b.options.Quorum {
+		b.logger.Error("quorum of ", b.options.Quorum, " cannot be reached for receipt of ", txHash.Hex(), ", only ", answeredCount, " of ", len(b.endpoints), " backend endpoints answered")
+	}
+
+	// a receipt missing on some endpoints is treated as not mined yet, the caller asks again later
+	if len(answers) == 1 || notFound > 0 {
+		return nil, ethereum.NotFound
+	}
+
+	return nil, err
+}
+
+// receiptKey identifies the content of a receipt, its consensus fields including the logs and the block it is part of
+func receiptKey(receipt *types.Receipt) common.Hash {
+	var buf bytes.Buffer
+	types.Receipts{receipt}.EncodeIndex(0, &buf)
+
+	return crypto.Keccak256Hash(receipt.TxHash.Bytes(), receipt.BlockHash.Bytes(), buf.Bytes())
+}
+
+func (b *MultiBackend) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		tx, isPending, err = backend.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (b *MultiBackend) BlockNumber(ctx context.Context) (blockNumber uint64, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		blockNumber, err = backend.BlockNumber(ctx)
+		return err
+	})
+	return blockNumber, err
+}
+
+func (b *MultiBackend) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		header, err = backend.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (b *MultiBackend) BalanceAt(ctx context.Context, address common.Address, block *big.Int) (balance *big.Int, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		balance, err = backend.BalanceAt(ctx, address, block)
+		return err
+	})
+	return balance, err
+}
+
+func (b *MultiBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		nonce, err = backend.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+func (b *MultiBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		code, err = backend.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (b *MultiBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (result []byte, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		result, err = backend.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+func (b *MultiBackend) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		nonce, err = backend.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (b *MultiBackend) SuggestGasPrice(ctx context.Context) (gasPrice *big.Int, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		gasPrice, err = backend.SuggestGasPrice(ctx)
+		return err
+	})
+	return gasPrice, err
+}
+
+func (b *MultiBackend) SuggestGasTipCap(ctx context.Context) (gasTipCap *big.Int, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		gasTipCap, err = backend.SuggestGasTipCap(ctx)
+		return err
+	})
+	return gasTipCap, err
+}
+
+func (b *MultiBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		gas, err = backend.EstimateGas(ctx, call)
+		return err
+	})
+	return gas, err
+}
+
+func (b *MultiBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return b.call(ctx, func(backend Backend) error {
+		return backend.SendTransaction(ctx, tx)
+	})
+}
+
+func (b *MultiBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		logs, err = backend.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+func (b *MultiBackend) ChainID(ctx context.Context) (chainID *big.Int, err error) {
+	err = b.call(ctx, func(backend Backend) error {
+		var err error
+		chainID, err = backend.ChainID(ctx)
+		return err
+	})
+	return chainID, err
+}
+
+// Close stops the health checks and closes every endpoint
+func (b *MultiBackend) Close() {
+	b.cancel()
+	b.wg.Wait()
+
+	for _, e := range b.endpoints {
+		e.backend.Close()
+	}
+}
@@ -0,0 +1,231 @@
+package transaction_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+var errUnreachable = errors.New("connection refused")
+
+// endpointMock answers with a fixed block number and receipt, any other call panics on the nil embedded backend
+type endpointMock struct {
+	transaction.Backend
+
+	lock       sync.Mutex
+	calls      int
+	blockErr   error
+	receipt    *types.Receipt
+	receiptErr error
+	blockTime  time.Time
+}
+
+func (e *endpointMock) BlockNumber(ctx context.Context) (uint64, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.calls++
+	return 100, e.blockErr
+}
+
+func (e *endpointMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: number, Time: uint64(e.blockTime.Unix())}, nil
+}
+
+func (e *endpointMock) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.calls++
+	return e.receipt, e.receiptErr
+}
+
+func (e *endpointMock) Close() {}
+
+func (e *endpointMock) callCount() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.calls
+}
+
+func newMultiBackend(t *testing.T, options transaction.MultiBackendOptions, endpoints ...*endpointMock) *transaction.MultiBackend {
+	t.Helper()
+
+	named := make([]transaction.Endpoint, 0, len(endpoints))
+	for i, e := range endpoints {
+		named = append(named, transaction.Endpoint{Name: string(rune('a' + i)), Backend: e})
+	}
+
+	backend, err := transaction.NewMultiBackend(logrus.New(), named, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(backend.Close)
+
+	return backend
+}
+
+func TestMultiBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	txHash := common.HexToHash("0x01")
+
+	t.Run("failover", func(t *testing.T) {
+		t.Parallel()
+
+		first := &endpointMock{blockErr: errUnreachable}
+		second := &endpointMock{}
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{}, first, second)
+
+		for i := 0; i < 2; i++ {
+			block, err := backend.BlockNumber(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block != 100 {
+				t.Fatalf("unexpected block number: %d", block)
+			}
+		}
+
+		// the failing endpoint lost its score and is not tried first anymore
+		if first.callCount() != 1 || second.callCount() != 2 {
+			t.Fatalf("unexpected calls: first %d second %d", first.callCount(), second.callCount())
+		}
+	})
+
+	t.Run("not found is an answer", func(t *testing.T) {
+		t.Parallel()
+
+		first := &endpointMock{receiptErr: ethereum.NotFound}
+		second := &endpointMock{receipt: &types.Receipt{TxHash: txHash}}
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{}, first, second)
+
+		_, err := backend.TransactionReceipt(ctx, txHash)
+		if !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+		if second.callCount() != 0 {
+			t.Fatal("not found must not fail over")
+		}
+	})
+
+	t.Run("all endpoints fail", func(t *testing.T) {
+		t.Parallel()
+
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{}, &endpointMock{blockErr: errUnreachable}, &endpointMock{blockErr: errUnreachable})
+
+		_, err := backend.BlockNumber(ctx)
+		if !errors.Is(err, errUnreachable) {
+			t.Fatalf("expected the last error, got %v", err)
+		}
+	})
+
+	t.Run("unhealthy endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		lagging := &endpointMock{blockTime: time.Now().Add(-time.Hour), receipt: &types.Receipt{TxHash: common.HexToHash("0x02")}}
+		synced := &endpointMock{blockTime: time.Now(), receipt: &types.Receipt{TxHash: txHash}}
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{HealthCheckInterval: 10 * time.Millisecond, MaxSyncDelay: time.Minute}, lagging, synced)
+
+		// the synced endpoint is checked after the lagging one
+		for deadline := time.Now().Add(5 * time.Second); synced.callCount() == 0; {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for health check")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		receipt, err := backend.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.TxHash != txHash {
+			t.Fatal("expected the synced endpoint to be called first")
+		}
+	})
+
+	t.Run("quorum", func(t *testing.T) {
+		t.Parallel()
+
+		receipt := &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful, BlockHash: common.HexToHash("0x02")}
+		forged := &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful, BlockHash: common.HexToHash("0x02"), Logs: []*types.Log{{Data: []byte{1}}}}
+
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{Quorum: 2},
+			&endpointMock{receipt: forged},
+			&endpointMock{receipt: receipt},
+			&endpointMock{receipt: receipt})
+
+		got, err := backend.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Logs) != 0 {
+			t.Fatal("expected the receipt returned by the quorum")
+		}
+	})
+
+	t.Run("quorum too large", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := transaction.NewMultiBackend(logrus.New(), []transaction.Endpoint{{Name: "a", Backend: &endpointMock{}}}, transaction.MultiBackendOptions{Quorum: 2})
+		if !errors.Is(err, transaction.ErrQuorumTooLarge) {
+			t.Fatalf("expected quorum too large, got %v", err)
+		}
+	})
+
+	t.Run("too few answers", func(t *testing.T) {
+		t.Parallel()
+
+		logger, hook := test.NewNullLogger()
+		backend, err := transaction.NewMultiBackend(logger, []transaction.Endpoint{
+			{Name: "a", Backend: &endpointMock{receipt: &types.Receipt{TxHash: txHash}}},
+			{Name: "b", Backend: &endpointMock{receiptErr: errUnreachable}},
+		}, transaction.MultiBackendOptions{Quorum: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(backend.Close)
+
+		_, err = backend.TransactionReceipt(ctx, txHash)
+		if !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("expected not found, got %v", err)
+		}
+
+		logged := false
+		for _, entry := range hook.AllEntries() {
+			logged = logged || entry.Level == logrus.ErrorLevel
+		}
+		if !logged {
+			t.Fatal("expected the missing quorum to be logged as error")
+		}
+	})
+
+	t.Run("no quorum", func(t *testing.T) {
+		t.Parallel()
+
+		receipt := &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful}
+		forged := &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusFailed}
+
+		backend := newMultiBackend(t, transaction.MultiBackendOptions{Quorum: 2},
+			&endpointMock{receipt: forged},
+			&endpointMock{receipt: receipt},
+			&endpointMock{receiptErr: errUnreachable})
+
+		_, err := backend.TransactionReceipt(ctx, txHash)
+		if !errors.Is(err, transaction.ErrNoQuorum) {
+			t.Fatalf("expected no quorum, got %v", err)
+		}
+	})
+}
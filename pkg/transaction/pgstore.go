@@ -0,0 +1,254 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// pgStoreTimeout bounds the queries of the store, its methods are called without a context
+const pgStoreTimeout = 10 * time.Second
+
+//nolint:golint,gochecknoglobals,varnamelen
+var qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type pgStore struct {
+	pg *pgxpool.Pool
+}
+
+// NewPostgresStore returns a store that keeps the sent transactions and the nonces in postgres so they survive restarts
+func NewPostgresStore(pool *pgxpool.Pool) Store {
+	return &pgStore{pg: pool}
+}
+
+func (s *pgStore) PutTransaction(txHash common.Hash, tx *StoredTransaction) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	var to *string
+	if tx.To != nil {
+		address := tx.To.Hex()
+		to = &address
+	}
+
+	// a nil slice would be written as NULL, transactions without data like cancellations store an empty one
+	data := tx.Data
+	if data == nil {
+		data = []byte{}
+	}
+
+	status := tx.Status
+	if len(status) == 0 {
+		status = TransactionStatusPending
+	}
+
+	sqls, args, err := qb.Insert("sent_transaction").
+		Columns("txHash", "toAddress", "data", "gasPrice", "gasLimit", "gasTipBoost", "gasTipCap", "gasFeeCap", "value", "nonce", "created", "description", "status", "fee", "cancellation").
+		Values(txHash.Hex(), to, data, bigToString(tx.GasPrice), tx.GasLimit, tx.GasTipBoost, bigToString(tx.GasTipCap), bigToString(tx.GasFeeCap), bigToString(tx.Value), tx.Nonce, tx.Created, tx.Description, status, nullableBig(tx.Fee), tx.Cancellation).
+		Suffix("ON CONFLICT (txHash) DO UPDATE SET status = EXCLUDED.status, fee = EXCLUDED.fee, updatedAt = CURRENT_TIMESTAMP").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query for put transaction: %w", err)
+	}
+
+	if _, err := s.pg.Exec(ctx, sqls, args...); err != nil {
+		return fmt.Errorf("error executing query for put transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) Transaction(txHash common.Hash) (*StoredTransaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	sqls, args, err := qb.Select("toAddress", "data", "gasPrice", "gasLimit", "gasTipBoost", "gasTipCap", "gasFeeCap", "value", "nonce", "created", "description", "status", "fee", "cancellation").
+		From("sent_transaction").
+		Where(sq.Eq{"txHash": txHash.Hex()}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for get transaction: %w", err)
+	}
+
+	var (
+		tx                                     StoredTransaction
+		to, fee                                *string
+		gasPrice, gasTipCap, gasFeeCap, amount string
+	)
+
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&to, &tx.Data, &gasPrice, &tx.GasLimit, &tx.GasTipBoost, &gasTipCap, &gasFeeCap, &amount, &tx.Nonce, &tx.Created, &tx.Description, &tx.Status, &fee, &tx.Cancellation)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUnknownTransaction
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for get transaction: %w", err)
+	}
+
+	if to != nil {
+		address := common.HexToAddress(*to)
+		tx.To = &address
+	}
+
+	tx.GasPrice, err = parseBig(gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	tx.GasTipCap, err = parseBig(gasTipCap)
+	if err != nil {
+		return nil, err
+	}
+	tx.GasFeeCap, err = parseBig(gasFeeCap)
+	if err != nil {
+		return nil, err
+	}
+	tx.Value, err = parseBig(amount)
+	if err != nil {
+		return nil, err
+	}
+	if fee != nil {
+		tx.Fee, err = parseBig(*fee)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &tx, nil
+}
+
+func (s *pgStore) SetStatus(txHash common.Hash, status string, fee *big.Int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	sqls, args, err := qb.Update("sent_transaction").
+		Set("status", status).
+		Set("fee", nullableBig(fee)).
+		Set("updatedAt", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"txHash": txHash.Hex()}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query for set transaction status: %w", err)
+	}
+
+	tag, err := s.pg.Exec(ctx, sqls, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query for set transaction status: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrUnknownTransaction
+	}
+
+	return nil
+}
+
+func (s *pgStore) PendingTransactions() ([]common.Hash, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	sqls, args, err := qb.Select("txHash").
+		From("sent_transaction").
+		Where(sq.Eq{"status": TransactionStatusPending}).
+		OrderBy("nonce").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building query for pending transactions: %w", err)
+	}
+
+	rows, err := s.pg.Query(ctx, sqls, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query for pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	pending := make([]common.Hash, 0)
+	for rows.Next() {
+		var txHash string
+		err := rows.Scan(&txHash)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning for pending transactions: %w", err)
+		}
+		pending = append(pending, common.HexToHash(txHash))
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error reading rows for pending transactions: %w", rows.Err())
+	}
+
+	return pending, nil
+}
+
+func (s *pgStore) PutNonce(sender common.Address, nonce uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	sqls, args, err := qb.Insert("sender_nonce").
+		Columns("sender", "nonce").
+		Values(strings.ToLower(sender.Hex()), nonce).
+		Suffix("ON CONFLICT (sender) DO UPDATE SET nonce = EXCLUDED.nonce, updatedAt = CURRENT_TIMESTAMP").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("error building query for put nonce: %w", err)
+	}
+
+	if _, err := s.pg.Exec(ctx, sqls, args...); err != nil {
+		return fmt.Errorf("error executing query for put nonce: %w", err)
+	}
+
+	return nil
+}
+
+func (s *pgStore) Nonce(sender common.Address) (uint64, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgStoreTimeout)
+	defer cancel()
+
+	sqls, args, err := qb.Select("nonce").
+		From("sender_nonce").
+		Where(sq.Eq{"sender": strings.ToLower(sender.Hex())}).
+		ToSql()
+	if err != nil {
+		return 0, false, fmt.Errorf("error building query for get nonce: %w", err)
+	}
+
+	var nonce uint64
+	err = s.pg.QueryRow(ctx, sqls, args...).Scan(&nonce)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error executing query for get nonce: %w", err)
+	}
+
+	return nonce, true, nil
+}
+
+// big integers are stored as decimal strings, postgres has no unsigned 256 bit type
+func bigToString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+func nullableBig(value *big.Int) *string {
+	if value == nil {
+		return nil
+	}
+	s := value.String()
+	return &s
+}
+
+func parseBig(value string) (*big.Int, error) {
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid stored amount: %s", value)
+	}
+	return parsed, nil
+}
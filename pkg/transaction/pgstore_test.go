@@ -0,0 +1,82 @@
+package transaction_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// newTestPostgresStore connects to the database of TEST_DATABASE_URL and creates the tables of the store,
+// the test is skipped when no database is configured
+func newTestPostgresStore(t *testing.T) transaction.Store {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if len(databaseURL) == 0 {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	pool, err := pgxpool.Connect(context.Background(), databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	migration, err := os.ReadFile("../../migrations/0008_transaction.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := strings.Split(string(migration), "---- create above / drop below ----")[0]
+	if _, err := pool.Exec(context.Background(), create); err != nil {
+		t.Fatal(err)
+	}
+
+	return transaction.NewPostgresStore(pool)
+}
+
+func TestPostgresStore(t *testing.T) {
+	t.Parallel()
+
+	store := newTestPostgresStore(t)
+
+	t.Run("nil data", func(t *testing.T) {
+		t.Parallel()
+
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender := crypto.PubkeyToAddress(privateKey.PublicKey)
+		txHash := crypto.Keccak256Hash(sender.Bytes())
+
+		err = store.PutTransaction(txHash, &transaction.StoredTransaction{
+			To:           &sender,
+			GasPrice:     big.NewInt(100),
+			GasLimit:     21000,
+			GasTipCap:    big.NewInt(10),
+			GasFeeCap:    big.NewInt(110),
+			Value:        new(big.Int),
+			Description:  "cancel " + common.Hash{}.Hex(),
+			Cancellation: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := store.Transaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stored.Data) != 0 || stored.Status != transaction.TransactionStatusPending || !stored.Cancellation {
+			t.Fatalf("unexpected stored transaction: %+v", stored)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package transaction
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// status of a transaction sent by the service
+const (
+	// TransactionStatusPending transactions were sent but are not mined yet
+	TransactionStatusPending = "pending"
+	// TransactionStatusConfirmed transactions were mined successfully
+	TransactionStatusConfirmed = "confirmed"
+	// TransactionStatusReverted transactions were mined but reverted
+	TransactionStatusReverted = "reverted"
+	// TransactionStatusReplaced transactions lost their nonce to another transaction
+	TransactionStatusReplaced = "replaced"
+	// TransactionStatusFailed transactions were stored but never broadcast
+	TransactionStatusFailed = "failed"
+)
+
+// Store persists the transactions sent by the service and the next nonce of the sender
+type Store interface {
+	// PutTransaction stores a transaction sent by the service.
+	PutTransaction(txHash common.Hash, tx *StoredTransaction) error
+	// Transaction returns a transaction sent by the service, ErrUnknownTransaction if it was not sent by it.
+	Transaction(txHash common.Hash) (*StoredTransaction, error)
+	// SetStatus updates the status of a transaction and the fee it paid, fee is nil while it is not mined.
+	SetStatus(txHash common.Hash, status string, fee *big.Int) error
+	// PendingTransactions returns the hashes of the pending transactions ordered by nonce.
+	PendingTransactions() ([]common.Hash, error)
+	// PutNonce stores the next nonce of the sender.
+	PutNonce(sender common.Address, nonce uint64) error
+	// Nonce returns the next nonce of the sender, false if none was stored yet.
+	Nonce(sender common.Address) (uint64, bool, error)
+}
+
+type memoryStore struct {
+	lock         sync.Mutex
+	transactions map[common.Hash]StoredTransaction
+	nonces       map[common.Address]uint64
+}
+
+// NewMemoryStore returns a store that keeps everything in memory, it is lost on restart
+func NewMemoryStore() Store {
+	return &memoryStore{
+		transactions: make(map[common.Hash]StoredTransaction),
+		nonces:       make(map[common.Address]uint64),
+	}
+}
+
+func (s *memoryStore) PutTransaction(txHash common.Hash, tx *StoredTransaction) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.transactions[txHash] = *tx
+	return nil
+}
+
+func (s *memoryStore) Transaction(txHash common.Hash) (*StoredTransaction, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, ok := s.transactions[txHash]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+
+	return &tx, nil
+}
+
+func (s *memoryStore) PutNonce(sender common.Address, nonce uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nonces[sender] = nonce
+	return nil
+}
+
+func (s *memoryStore) Nonce(sender common.Address) (uint64, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nonce, ok := s.nonces[sender]
+	return nonce, ok, nil
+}
+
+func (s *memoryStore) SetStatus(txHash common.Hash, status string, fee *big.Int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tx, ok := s.transactions[txHash]
+	if !ok {
+		return ErrUnknownTransaction
+	}
+
+	tx.Status = status
+	tx.Fee = fee
+	s.transactions[txHash] = tx
+	return nil
+}
+
+func (s *memoryStore) PendingTransactions() ([]common.Hash, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pending := make([]common.Hash, 0)
+	for txHash, tx := range s.transactions {
+		if tx.Status == TransactionStatusPending {
+			pending = append(pending, txHash)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return s.transactions[pending[i]].Nonce < s.transactions[pending[j]].Nonce
+	})
+
+	return pending, nil
+}
@@ -0,0 +1,51 @@
+package transaction
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// syncCheckTTL is how long the result of a sync check is reused, it is asked on every verification and readiness probe
+const syncCheckTTL = 5 * time.Second
+
+// SyncChecker tells whether the backend is synced, the result of the last successful check is cached for a few seconds
+type SyncChecker struct {
+	lock      sync.Mutex
+	reader    ChainReader
+	maxDelay  time.Duration
+	checked   time.Time
+	synced    bool
+	blockTime time.Time
+}
+
+// NewSyncChecker creates a checker considering the backend synced while its last block is at most maxDelay old
+func NewSyncChecker(reader ChainReader, maxDelay time.Duration) *SyncChecker {
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxSyncDelay
+	}
+
+	return &SyncChecker{
+		reader:   reader,
+		maxDelay: maxDelay,
+	}
+}
+
+// Synced returns whether the backend is synced and the time of its last block
+func (c *SyncChecker) Synced(ctx context.Context) (bool, time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if time.Since(c.checked) < syncCheckTTL {
+		return c.synced, c.blockTime, nil
+	}
+
+	synced, blockTime, err := IsSynced(ctx, c.reader, c.maxDelay)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	c.checked, c.synced, c.blockTime = time.Now(), synced, blockTime
+
+	return synced, blockTime, nil
+}
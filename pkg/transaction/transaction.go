@@ -13,6 +13,7 @@ import (
 	"io"
 	"math/big"
 	"sync"
+	"time"
 )
 
 var (
@@ -36,17 +37,20 @@ type TxRequest struct {
 }
 
 type StoredTransaction struct {
-	To          *common.Address // recipient of the transaction
-	Data        []byte          // transaction data
-	GasPrice    *big.Int        // used gas price
-	GasLimit    uint64          // used gas limit
-	GasTipBoost int             // adds a tip for the miner for prioritizing transaction
-	GasTipCap   *big.Int        // adds a cap to the tip
-	GasFeeCap   *big.Int        // adds a cap to maximum fee user is willing to pay
-	Value       *big.Int        // amount of wei to send
-	Nonce       uint64          // used nonce
-	Created     int64           // creation timestamp
-	Description string          // description
+	To           *common.Address // recipient of the transaction
+	Data         []byte          // transaction data
+	GasPrice     *big.Int        // used gas price
+	GasLimit     uint64          // used gas limit
+	GasTipBoost  int             // adds a tip for the miner for prioritizing transaction
+	GasTipCap    *big.Int        // adds a cap to the tip
+	GasFeeCap    *big.Int        // adds a cap to maximum fee user is willing to pay
+	Value        *big.Int        // amount of wei to send
+	Nonce        uint64          // used nonce
+	Created      int64           // creation timestamp
+	Description  string          // description
+	Status       string          // status of the transaction, updated once it is mined
+	Fee          *big.Int        // fee paid by the transaction or nil while it is not mined
+	Cancellation bool            // set on the transactions sent by CancelTransaction
 }
 
 // Service is the service to send transactions. It takes care of gas price, gas
@@ -77,6 +81,12 @@ type Service interface {
 	TransactionFee(ctx context.Context, txHash common.Hash) (*big.Int, error)
 	// FilterLogs filters the events from contract
 	FilterLogs(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error)
+	// BlockNumber returns the number of the most recent block
+	BlockNumber(ctx context.Context) (uint64, error)
+	// HeaderByNumber returns the header of the canonical block with the given number
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	// Balance returns the ether balance of the sender
+	Balance(ctx context.Context) (*big.Int, error)
 }
 
 type TxService struct {
@@ -88,9 +98,14 @@ type TxService struct {
 	logger    *logrus.Logger
 	backend   WrappedBackend
 	signer    signer.Signer
+	store     Store
 	sender    common.Address
 	chainID   *big.Int
 	rpcClient *rpc.Client
+	options   MonitorOptions
+
+	watchLock sync.Mutex
+	watches   map[uint64][]watch
 }
 
 func (t *TxService) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error) {
@@ -103,14 +118,235 @@ func (t *TxService) FilterLogs(ctx context.Context, query ethereum.FilterQuery)
 	return &filteredLogs, nil
 }
 
+func (t *TxService) BlockNumber(ctx context.Context) (uint64, error) {
+	return t.backend.BlockNumber(ctx)
+}
+
+func (t *TxService) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return t.backend.HeaderByNumber(ctx, number)
+}
+
+func (t *TxService) Balance(ctx context.Context) (*big.Int, error) {
+	return t.backend.BalanceAt(ctx, t.sender, nil)
+}
+
+// Close stops the transaction monitor and closes the backend, watches that did not get a receipt yet receive
+// ErrMonitorClosed
 func (t *TxService) Close() error {
-	//TODO implement me
-	panic("implement me")
+	t.cancel()
+	t.wg.Wait()
+	t.backend.Close()
+
+	t.watchLock.Lock()
+	defer t.watchLock.Unlock()
+
+	for nonce, watches := range t.watches {
+		for _, w := range watches {
+			w.errC <- ErrMonitorClosed
+		}
+		delete(t.watches, nonce)
+	}
+
+	return nil
 }
 
+// Send creates a transaction based on the request and sends it, the nonce is reserved under the service lock
+// so concurrent sends never reuse a nonce.
 func (t *TxService) Send(ctx context.Context, request *TxRequest, tipCapBoostPercent int) (txHash common.Hash, err error) {
-	//TODO implement me
-	panic("implement me")
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	nonce, err := t.nextNonce(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := t.prepareTransaction(ctx, request, nonce, tipCapBoostPercent)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	signedTx, err := t.signer.SignTx(tx, t.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// the transaction and the nonce are stored before broadcasting so the monitor tracks every sent transaction
+	err = t.storeTransaction(signedTx, tipCapBoostPercent, request.Description, false)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	err = t.store.PutNonce(t.sender, nonce+1)
+	if err != nil {
+		t.abandon(signedTx.Hash(), nonce)
+		return common.Hash{}, fmt.Errorf("unable to store nonce: %w", err)
+	}
+
+	err = t.send(ctx, signedTx, request.Description)
+	if err != nil {
+		t.abandon(signedTx.Hash(), nonce)
+		return common.Hash{}, err
+	}
+
+	return signedTx.Hash(), nil
+}
+
+// abandon marks a stored transaction that was not sent as failed and gives its nonce back, errors are only logged
+// as the caller already fails the send
+func (t *TxService) abandon(txHash common.Hash, nonce uint64) {
+	err := t.store.SetStatus(txHash, TransactionStatusFailed, nil)
+	if err != nil {
+		t.logger.Error("unable to mark transaction ", txHash.Hex(), " as failed: ", err)
+	}
+
+	err = t.store.PutNonce(t.sender, nonce)
+	if err != nil {
+		t.logger.Error("unable to give back nonce ", nonce, ": ", err)
+	}
+}
+
+// signAndSend signs the transaction with the node key and sends it to the backend
+func (t *TxService) signAndSend(ctx context.Context, tx *types.Transaction, description string) (*types.Transaction, error) {
+	signedTx, err := t.signer.SignTx(tx, t.chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.send(ctx, signedTx, description)
+	if err != nil {
+		return nil, err
+	}
+
+	return signedTx, nil
+}
+
+// send broadcasts a signed transaction
+func (t *TxService) send(ctx context.Context, signedTx *types.Transaction, description string) error {
+	t.logger.Debug("sending transaction: ", signedTx.Hash().Hex(), " nonce: ", signedTx.Nonce(), " description: ", description)
+
+	return t.backend.SendTransaction(ctx, signedTx)
+}
+
+// storeTransaction records a sent transaction as pending, cancellation marks the transactions sent by CancelTransaction
+func (t *TxService) storeTransaction(signedTx *types.Transaction, tipCapBoostPercent int, description string, cancellation bool) error {
+	err := t.store.PutTransaction(signedTx.Hash(), &StoredTransaction{
+		To:           signedTx.To(),
+		Data:         signedTx.Data(),
+		GasPrice:     signedTx.GasPrice(),
+		GasLimit:     signedTx.Gas(),
+		GasTipBoost:  tipCapBoostPercent,
+		GasTipCap:    signedTx.GasTipCap(),
+		GasFeeCap:    signedTx.GasFeeCap(),
+		Value:        signedTx.Value(),
+		Nonce:        signedTx.Nonce(),
+		Created:      time.Now().Unix(),
+		Description:  description,
+		Status:       TransactionStatusPending,
+		Cancellation: cancellation,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store transaction: %w", err)
+	}
+
+	return nil
+}
+
+// nextNonce returns the nonce of the next transaction, the pending nonce of the backend wins when
+// transactions were sent by someone else with the same key
+func (t *TxService) nextNonce(ctx context.Context) (uint64, error) {
+	onchainNonce, err := t.backend.PendingNonceAt(ctx, t.sender)
+	if err != nil {
+		return 0, err
+	}
+
+	nonce, found, err := t.store.Nonce(t.sender)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get stored nonce: %w", err)
+	}
+
+	if !found || onchainNonce > nonce {
+		return onchainNonce, nil
+	}
+
+	return nonce, nil
+}
+
+// prepareTransaction creates the dynamic fee transaction for the request, an estimated gas limit gets 25% on top
+func (t *TxService) prepareTransaction(ctx context.Context, request *TxRequest, nonce uint64, tipCapBoostPercent int) (*types.Transaction, error) {
+	gasLimit := request.GasLimit
+	if gasLimit == 0 {
+		estimated, err := t.backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:  t.sender,
+			To:    request.To,
+			Data:  request.Data,
+			Value: request.Value,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		gasLimit = estimated + estimated/4
+		if gasLimit < request.MinEstimatedGasLimit {
+			gasLimit = request.MinEstimatedGasLimit
+		}
+	}
+
+	gasFeeCap, gasTipCap, err := t.suggestedFeeAndTip(ctx, request.GasPrice, tipCapBoostPercent)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.GasFeeCap != nil && gasFeeCap.Cmp(request.GasFeeCap) > 0 {
+		gasFeeCap = new(big.Int).Set(request.GasFeeCap)
+	}
+	if gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasTipCap = new(big.Int).Set(gasFeeCap)
+	}
+
+	value := request.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   t.chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        request.To,
+		Value:     value,
+		Data:      request.Data,
+	}), nil
+}
+
+// suggestedFeeAndTip returns the fee cap and the tip cap of a transaction, both boosted by tipCapBoostPercent.
+// The fee cap leaves room for the base fee to grow by the suggested gas price.
+func (t *TxService) suggestedFeeAndTip(ctx context.Context, gasPrice *big.Int, tipCapBoostPercent int) (*big.Int, *big.Int, error) {
+	var err error
+	if gasPrice == nil {
+		gasPrice, err = t.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		gasPrice = boost(gasPrice, tipCapBoostPercent)
+	}
+
+	gasTipCap, err := t.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	gasTipCap = boost(gasTipCap, tipCapBoostPercent)
+
+	gasFeeCap := new(big.Int).Add(gasTipCap, gasPrice)
+
+	return gasFeeCap, gasTipCap, nil
+}
+
+func boost(value *big.Int, percent int) *big.Int {
+	boosted := new(big.Int).Mul(value, big.NewInt(int64(percent)+100))
+	return boosted.Div(boosted, big.NewInt(100))
 }
 
 func (t *TxService) Call(ctx context.Context, request *TxRequest) (result []byte, err error) {
@@ -131,40 +367,75 @@ func (t *TxService) Call(ctx context.Context, request *TxRequest) (result []byte
 }
 
 func (t *TxService) WaitForReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
-	return t.backend.TransactionReceipt(ctx, txHash)
-}
-
-func (t *TxService) WatchSentTransaction(txHash common.Hash) (<-chan types.Receipt, <-chan error, error) {
-	//TODO implement me
-	panic("implement me")
+	return t.backend.WaitForReceipt(ctx, txHash)
 }
 
 func (t *TxService) StoredTransaction(txHash common.Hash) (*StoredTransaction, error) {
-	//TODO implement me
-	panic("implement me")
+	return t.store.Transaction(txHash)
 }
 
 func (t *TxService) PendingTransactions() ([]common.Hash, error) {
-	//TODO implement me
-	panic("implement me")
+	return t.store.PendingTransactions()
 }
 
-func (t *TxService) ResendTransaction(ctx context.Context, txHash common.Hash) error {
-	//TODO implement me
-	panic("implement me")
+// TransactionFee returns the fee paid by a transaction sent by the service, it is read from the receipt the first
+// time and stored with the status of the transaction afterwards
+func (t *TxService) TransactionFee(ctx context.Context, txHash common.Hash) (*big.Int, error) {
+	stored, err := t.store.Transaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.Fee != nil {
+		return stored.Fee, nil
+	}
+
+	receipt, err := t.backend.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := receiptFee(receipt, stored)
+
+	err = t.store.SetStatus(txHash, receiptStatus(receipt), fee)
+	if err != nil {
+		return nil, fmt.Errorf("unable to store transaction status: %w", err)
+	}
+
+	return fee, nil
 }
 
-func (t *TxService) CancelTransaction(ctx context.Context, originalTxHash common.Hash) (common.Hash, error) {
-	//TODO implement me
-	panic("implement me")
+// receiptStatus returns the status of a mined transaction
+func receiptStatus(receipt *types.Receipt) string {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return TransactionStatusConfirmed
+	}
+	return TransactionStatusReverted
 }
 
-func (t *TxService) TransactionFee(ctx context.Context, txHash common.Hash) (*big.Int, error) {
-	//TODO implement me
-	panic("implement me")
+// receiptFee returns the fee paid by a mined transaction, when the backend does not report the effective gas price
+// the gas price of the transaction is used, which is the fee cap of dynamic fee transactions
+func receiptFee(receipt *types.Receipt, stored *StoredTransaction) *big.Int {
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = stored.GasPrice
+	}
+
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), gasPrice)
 }
 
-func NewTxService(rpcClient *rpc.Client, logger *logrus.Logger, backend WrappedBackend, signer signer.Signer, chainID *big.Int, address common.Address) (Service, error) {
+// NewTxService creates the transaction service and starts its transaction monitor, Close stops the monitor
+func NewTxService(rpcClient *rpc.Client, logger *logrus.Logger, backend WrappedBackend, signer signer.Signer, store Store, chainID *big.Int, address common.Address, options MonitorOptions) (Service, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultMonitorPollInterval
+	}
+	if options.StuckAfter <= 0 {
+		options.StuckAfter = defaultMonitorStuckAfter
+	}
+	if options.ResendBoost <= 0 {
+		options.ResendBoost = defaultMonitorResendBoost
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	tx := &TxService{
 		wg:      sync.WaitGroup{},
@@ -174,8 +445,15 @@ func NewTxService(rpcClient *rpc.Client, logger *logrus.Logger, backend WrappedB
 		logger:  logger,
 		backend: backend,
 		signer:  signer,
+		store:   store,
 		sender:  address,
 		chainID: chainID,
+		options: options,
+		watches: make(map[uint64][]watch),
 	}
+
+	tx.wg.Add(1)
+	go tx.monitor()
+
 	return tx, nil
 }
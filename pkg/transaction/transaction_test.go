@@ -0,0 +1,592 @@
+package transaction_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gryd-database/platform-poc/pkg/signer"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// backendMock implements the calls used by the service, any other call panics on the nil embedded backend
+type backendMock struct {
+	transaction.Backend
+
+	lock         sync.Mutex
+	block        uint64
+	pendingNonce uint64
+	gasEstimate  uint64
+	gasPrice     *big.Int
+	gasTipCap    *big.Int
+	sendErr      error
+	sent         []*types.Transaction
+	receipts     map[common.Hash]*types.Receipt
+}
+
+func (b *backendMock) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.pendingNonce, nil
+}
+
+func (b *backendMock) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return b.gasEstimate, nil
+}
+
+func (b *backendMock) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return b.gasPrice, nil
+}
+
+func (b *backendMock) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gasTipCap, nil
+}
+
+func (b *backendMock) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *backendMock) Close() {}
+
+// sentTransactions returns a copy of the sent transactions, the monitor may send while the test reads them
+func (b *backendMock) sentTransactions() []*types.Transaction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]*types.Transaction(nil), b.sent...)
+}
+
+// BlockNumber returns a new block on every call so the monitor checks the pending transactions on every poll
+func (b *backendMock) BlockNumber(ctx context.Context) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.block++
+	return b.block, nil
+}
+
+func (b *backendMock) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 0, nil
+}
+
+func (b *backendMock) setReceipt(txHash common.Hash, receipt *types.Receipt) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.receipts[txHash] = receipt
+}
+
+func (b *backendMock) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	receipt, ok := b.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func newTestService(t *testing.T, backend *backendMock, store transaction.Store, options transaction.MonitorOptions) (transaction.Service, common.Address) {
+	t.Helper()
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := signer.New(common.Bytes2Hex(crypto.FromECDSA(privateKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txService, err := transaction.NewTxService(nil, logrus.New(), *transaction.NewBackend(backend, transaction.RetryOptions{}), s, store, big.NewInt(1), s.EthereumAddress(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		txService.Close()
+	})
+
+	return txService, s.EthereumAddress()
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	recipient := common.HexToAddress("0xabcd")
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{pendingNonce: 3, gasEstimate: 40000, gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10)}
+		store := transaction.NewMemoryStore()
+		txService, sender := newTestService(t, backend, store, transaction.MonitorOptions{})
+
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{
+			To:                   &recipient,
+			Data:                 []byte{1, 2, 3},
+			MinEstimatedGasLimit: 60000,
+			Description:          "test",
+		}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(backend.sent) != 1 {
+			t.Fatalf("expected one sent transaction, got %d", len(backend.sent))
+		}
+
+		tx := backend.sent[0]
+		if tx.Hash() != txHash || tx.Type() != types.DynamicFeeTxType || tx.Nonce() != 3 {
+			t.Fatalf("unexpected transaction: %+v", tx)
+		}
+
+		// the estimate plus 25% is below the minimum
+		if tx.Gas() != 60000 {
+			t.Fatalf("expected gas limit 60000, got %d", tx.Gas())
+		}
+
+		if tx.GasTipCap().Int64() != 11 || tx.GasFeeCap().Int64() != 121 {
+			t.Fatalf("unexpected fees: tip %d cap %d", tx.GasTipCap(), tx.GasFeeCap())
+		}
+
+		from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), tx)
+		if err != nil || from != sender {
+			t.Fatalf("unexpected sender %s: %v", from.Hex(), err)
+		}
+
+		stored, err := store.Transaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Nonce != 3 || stored.Description != "test" || stored.GasTipBoost != 10 {
+			t.Fatalf("unexpected stored transaction: %+v", stored)
+		}
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{pendingNonce: 5, gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10)}
+		txService, _ := newTestService(t, backend, transaction.NewMemoryStore(), transaction.MonitorOptions{})
+
+		for i := 0; i < 2; i++ {
+			_, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the backend does not see the pending transactions yet, the stored nonce must be used
+		if backend.sent[0].Nonce() != 5 || backend.sent[1].Nonce() != 6 {
+			t.Fatalf("unexpected nonces: %d %d", backend.sent[0].Nonce(), backend.sent[1].Nonce())
+		}
+
+		backend.pendingNonce = 10
+		_, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if backend.sent[2].Nonce() != 10 {
+			t.Fatalf("expected the backend nonce to win, got %d", backend.sent[2].Nonce())
+		}
+	})
+
+	t.Run("fee cap", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10)}
+		txService, _ := newTestService(t, backend, transaction.NewMemoryStore(), transaction.MonitorOptions{})
+
+		_, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000, GasFeeCap: big.NewInt(5)}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if backend.sent[0].GasFeeCap().Int64() != 5 || backend.sent[0].GasTipCap().Int64() != 5 {
+			t.Fatalf("unexpected fees: tip %d cap %d", backend.sent[0].GasTipCap(), backend.sent[0].GasFeeCap())
+		}
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{pendingNonce: 2, gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), sendErr: errors.New("send failed")}
+		store := transaction.NewMemoryStore()
+		txService, sender := newTestService(t, backend, store, transaction.MonitorOptions{})
+
+		_, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if !errors.Is(err, backend.sendErr) {
+			t.Fatalf("expected send error, got %v", err)
+		}
+
+		pending, err := store.PendingTransactions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Fatalf("expected no pending transactions, got %d", len(pending))
+		}
+
+		nonce, _, err := store.Nonce(sender)
+		if err != nil || nonce != 2 {
+			t.Fatalf("expected the nonce to be given back, got %d: %v", nonce, err)
+		}
+
+		backend.lock.Lock()
+		backend.sendErr = nil
+		backend.lock.Unlock()
+
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if backend.sent[0].Hash() != txHash || backend.sent[0].Nonce() != 2 {
+			t.Fatalf("expected the nonce to be reused, got %d", backend.sent[0].Nonce())
+		}
+	})
+}
+
+func TestStoredTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	recipient := common.HexToAddress("0xabcd")
+
+	backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+	txService, _ := newTestService(t, backend, transaction.NewMemoryStore(), transaction.MonitorOptions{})
+
+	hashes := make([]common.Hash, 0, 2)
+	for i := 0; i < 2; i++ {
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, txHash)
+	}
+
+	pending, err := txService.PendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0] != hashes[0] || pending[1] != hashes[1] {
+		t.Fatalf("unexpected pending transactions: %v", pending)
+	}
+
+	_, err = txService.TransactionFee(ctx, hashes[0])
+	if !errors.Is(err, ethereum.NotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	backend.setReceipt(hashes[0], &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		GasUsed:           21000,
+		EffectiveGasPrice: big.NewInt(50),
+	})
+
+	fee, err := txService.TransactionFee(ctx, hashes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.Int64() != 21000*50 {
+		t.Fatalf("unexpected fee: %d", fee)
+	}
+
+	stored, err := txService.StoredTransaction(hashes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != transaction.TransactionStatusConfirmed || stored.Fee.Cmp(fee) != 0 {
+		t.Fatalf("unexpected stored transaction: %+v", stored)
+	}
+
+	pending, err = txService.PendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0] != hashes[1] {
+		t.Fatalf("unexpected pending transactions: %v", pending)
+	}
+
+	_, err = txService.StoredTransaction(common.HexToHash("0x01"))
+	if !errors.Is(err, transaction.ErrUnknownTransaction) {
+		t.Fatalf("expected unknown transaction, got %v", err)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	recipient := common.HexToAddress("0xabcd")
+
+	t.Run("watch", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+		store := transaction.NewMemoryStore()
+		txService, _ := newTestService(t, backend, store, transaction.MonitorOptions{PollInterval: 10 * time.Millisecond})
+
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiptC, errC, err := txService.WatchSentTransaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		backend.setReceipt(txHash, &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, EffectiveGasPrice: big.NewInt(50)})
+
+		select {
+		case receipt := <-receiptC:
+			if receipt.TxHash != txHash {
+				t.Fatalf("unexpected receipt: %+v", receipt)
+			}
+		case err := <-errC:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for receipt")
+		}
+
+		stored, err := store.Transaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != transaction.TransactionStatusConfirmed || stored.Fee.Int64() != 21000*50 {
+			t.Fatalf("unexpected stored transaction: %+v", stored)
+		}
+	})
+
+	t.Run("stuck", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+		store := transaction.NewMemoryStore()
+		txService, _ := newTestService(t, backend, store, transaction.MonitorOptions{
+			PollInterval: 10 * time.Millisecond,
+			StuckAfter:   time.Nanosecond,
+			ResendBoost:  50,
+		})
+
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, Data: []byte{1}, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiptC, errC, err := txService.WatchSentTransaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sent []*types.Transaction
+		for deadline := time.Now().Add(5 * time.Second); len(sent) < 2; sent = backend.sentTransactions() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for replacement")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		original, replacement := sent[0], sent[1]
+		if replacement.Nonce() != original.Nonce() || replacement.GasTipCap().Int64() != 15 || replacement.GasFeeCap().Int64() != 165 {
+			t.Fatalf("unexpected replacement: nonce %d tip %d cap %d", replacement.Nonce(), replacement.GasTipCap(), replacement.GasFeeCap())
+		}
+		if string(replacement.Data()) != string(original.Data()) {
+			t.Fatal("replacement must repeat the call")
+		}
+
+		backend.setReceipt(replacement.Hash(), &types.Receipt{TxHash: replacement.Hash(), Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(1)})
+
+		select {
+		case receipt := <-receiptC:
+			if receipt.TxHash != replacement.Hash() {
+				t.Fatalf("expected the receipt of the replacement, got %s", receipt.TxHash.Hex())
+			}
+		case err := <-errC:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for receipt")
+		}
+
+		stored, err := store.Transaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != transaction.TransactionStatusReplaced {
+			t.Fatalf("expected the original to be replaced, got %s", stored.Status)
+		}
+	})
+
+	t.Run("self transfer", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+		txService, sender := newTestService(t, backend, transaction.NewMemoryStore(), transaction.MonitorOptions{
+			PollInterval: 10 * time.Millisecond,
+			StuckAfter:   time.Nanosecond,
+		})
+
+		// a zero value transfer to the sender looks like a cancellation but was not sent by CancelTransaction
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &sender, GasLimit: 30000, Value: new(big.Int)}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiptC, errC, err := txService.WatchSentTransaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sent []*types.Transaction
+		for deadline := time.Now().Add(5 * time.Second); len(sent) < 2; sent = backend.sentTransactions() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout waiting for replacement")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		replacement := sent[1]
+		if replacement.Gas() != 30000 {
+			t.Fatalf("expected the replacement to keep the gas limit, got %d", replacement.Gas())
+		}
+
+		backend.setReceipt(replacement.Hash(), &types.Receipt{TxHash: replacement.Hash(), Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(1)})
+
+		select {
+		case <-receiptC:
+		case err := <-errC:
+			t.Fatalf("expected the receipt of the replacement, got %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for receipt")
+		}
+	})
+
+	// the replacements must also be stored by the postgres store, a cancellation carries no data
+	for name, newStore := range map[string]func(t *testing.T) transaction.Store{
+		"memory":   func(t *testing.T) transaction.Store { return transaction.NewMemoryStore() },
+		"postgres": newTestPostgresStore,
+	} {
+		newStore := newStore
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("cancel", func(t *testing.T) {
+				t.Parallel()
+
+				backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+				txService, sender := newTestService(t, backend, newStore(t), transaction.MonitorOptions{PollInterval: 10 * time.Millisecond})
+
+				txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, Data: []byte{1}, GasLimit: 60000, Value: big.NewInt(1)}, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				receiptC, errC, err := txService.WatchSentTransaction(txHash)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				cancelHash, err := txService.CancelTransaction(ctx, txHash)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				cancelTx := backend.sentTransactions()[1]
+				if cancelTx.Hash() != cancelHash || cancelTx.Nonce() != 0 || *cancelTx.To() != sender || cancelTx.Value().Sign() != 0 || len(cancelTx.Data()) != 0 {
+					t.Fatalf("unexpected cancel transaction: %+v", cancelTx)
+				}
+
+				backend.setReceipt(cancelHash, &types.Receipt{TxHash: cancelHash, Status: types.ReceiptStatusSuccessful, EffectiveGasPrice: big.NewInt(1)})
+
+				select {
+				case <-receiptC:
+					t.Fatal("expected the cancellation error")
+				case err := <-errC:
+					if !errors.Is(err, transaction.ErrTransactionCancelled) {
+						t.Fatalf("expected cancelled, got %v", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timeout waiting for cancellation")
+				}
+
+				_, err = txService.CancelTransaction(ctx, txHash)
+				if !errors.Is(err, transaction.ErrTransactionNotPending) {
+					t.Fatalf("expected not pending, got %v", err)
+				}
+			})
+
+			t.Run("resend", func(t *testing.T) {
+				t.Parallel()
+
+				backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+				txService, _ := newTestService(t, backend, newStore(t), transaction.MonitorOptions{})
+
+				txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = txService.ResendTransaction(ctx, txHash)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				sent := backend.sentTransactions()
+				if len(sent) != 2 || sent[1].Hash() != txHash {
+					t.Fatalf("expected the same transaction to be sent again")
+				}
+			})
+		})
+	}
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &backendMock{gasPrice: big.NewInt(100), gasTipCap: big.NewInt(10), receipts: make(map[common.Hash]*types.Receipt)}
+		txService, _ := newTestService(t, backend, transaction.NewMemoryStore(), transaction.MonitorOptions{})
+
+		txHash, err := txService.Send(ctx, &transaction.TxRequest{To: &recipient, GasLimit: 21000}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, errC, err := txService.WatchSentTransaction(txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = txService.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := <-errC; !errors.Is(err, transaction.ErrMonitorClosed) {
+			t.Fatalf("expected monitor closed, got %v", err)
+		}
+
+		_, _, err = txService.WatchSentTransaction(txHash)
+		if !errors.Is(err, transaction.ErrMonitorClosed) {
+			t.Fatalf("expected monitor closed, got %v", err)
+		}
+	})
+}
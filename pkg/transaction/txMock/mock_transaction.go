@@ -24,6 +24,9 @@ type transactionServiceMock struct {
 	cancelTransaction    func(ctx context.Context, originalTxHash common.Hash) (common.Hash, error)
 	transactionFee       func(ctx context.Context, txHash common.Hash) (*big.Int, error)
 	filterLogs           func(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error)
+	blockNumber          func(ctx context.Context) (uint64, error)
+	headerByNumber       func(ctx context.Context, number *big.Int) (*types.Header, error)
+	balance              func(ctx context.Context) (*big.Int, error)
 }
 
 func (m *transactionServiceMock) Send(ctx context.Context, request *transaction.TxRequest, boostPercent int) (txHash common.Hash, err error) {
@@ -68,6 +71,20 @@ func (m *transactionServiceMock) FilterLogs(ctx context.Context, query ethereum.
 	return nil, errors.New("not implemented")
 }
 
+func (m *transactionServiceMock) BlockNumber(ctx context.Context) (uint64, error) {
+	if m.blockNumber != nil {
+		return m.blockNumber(ctx)
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *transactionServiceMock) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if m.headerByNumber != nil {
+		return m.headerByNumber(ctx, number)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *transactionServiceMock) ResendTransaction(ctx context.Context, txHash common.Hash) error {
 	if m.resendTransaction != nil {
 		return m.resendTransaction(ctx, txHash)
@@ -89,6 +106,13 @@ func (m *transactionServiceMock) CancelTransaction(ctx context.Context, original
 	return common.Hash{}, errors.New("not implemented")
 }
 
+func (m *transactionServiceMock) Balance(ctx context.Context) (*big.Int, error) {
+	if m.balance != nil {
+		return m.balance(ctx)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *transactionServiceMock) Close() error {
 	return nil
 }
@@ -158,6 +182,30 @@ func WithTransactionFeeFunc(f func(ctx context.Context, txHash common.Hash) (*bi
 	})
 }
 
+func WithFilterLogsFunc(f func(ctx context.Context, query ethereum.FilterQuery) (*[]types.Log, error)) Option {
+	return optionFunc(func(s *transactionServiceMock) {
+		s.filterLogs = f
+	})
+}
+
+func WithBlockNumberFunc(f func(ctx context.Context) (uint64, error)) Option {
+	return optionFunc(func(s *transactionServiceMock) {
+		s.blockNumber = f
+	})
+}
+
+func WithHeaderByNumberFunc(f func(ctx context.Context, number *big.Int) (*types.Header, error)) Option {
+	return optionFunc(func(s *transactionServiceMock) {
+		s.headerByNumber = f
+	})
+}
+
+func WithBalanceFunc(f func(ctx context.Context) (*big.Int, error)) Option {
+	return optionFunc(func(s *transactionServiceMock) {
+		s.balance = f
+	})
+}
+
 func New(opts ...Option) transaction.Service {
 	mock := new(transactionServiceMock)
 	for _, o := range opts {
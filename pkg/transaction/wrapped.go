@@ -3,29 +3,190 @@ package transaction
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	_ Backend = (*WrappedBackend)(nil)
+
+	tracer = otel.Tracer("github.com/gryd-database/platform-poc/pkg/transaction")
+)
+
+const (
+	defaultCallTimeout    = 30 * time.Second
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 250 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+	// rpcLimitExceeded is the json-rpc error code providers answer with when a request is rate limited
+	rpcLimitExceeded = -32005
 )
 
+// RetryOptions configures the calls of the wrapped backend, every attempt is bounded by Timeout and a retryable error
+// is retried at most MaxRetries times with a backoff doubling from InitialBackoff up to MaxBackoff. A receipt that is
+// not found is asked again by WaitForReceipt until ReceiptGrace passed.
+type RetryOptions struct {
+	Timeout        time.Duration
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	ReceiptGrace   time.Duration
+}
+
 type WrappedBackend struct {
 	backend Backend
+	options RetryOptions
 }
 
-func NewBackend(backend Backend) *WrappedBackend {
+func NewBackend(backend Backend, options RetryOptions) *WrappedBackend {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultCallTimeout
+	}
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = defaultMaxRetries
+	}
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = defaultInitialBackoff
+	}
+	if options.MaxBackoff < options.InitialBackoff {
+		options.MaxBackoff = defaultMaxBackoff
+	}
+
 	return &WrappedBackend{
 		backend: backend,
+		options: options,
+	}
+}
+
+// Retryable reports whether the error is transient and the call may succeed when repeated, errors answered by the
+// node such as reverts or invalid transactions are permanent
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		message := strings.ToLower(rpcErr.Error())
+		return rpcErr.ErrorCode() == rpcLimitExceeded || strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retry runs fn until it succeeds, fails with a permanent error or runs out of retries, each attempt gets its own timeout
+// and its latency is recorded under method
+func (b *WrappedBackend) retry(ctx context.Context, method string, fn func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "rpc "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.RPCSystemKey.String("jsonrpc"),
+		semconv.RPCMethodKey.String(method),
+	))
+	defer span.End()
+
+	backoff := b.options.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, b.options.Timeout)
+		start := time.Now()
+		err := fn(callCtx)
+		rpcDuration.WithLabelValues(method, callOutcome(err)).Observe(time.Since(start).Seconds())
+		cancel()
+
+		if err == nil || attempt >= b.options.MaxRetries || !Retryable(err) || ctx.Err() != nil {
+			span.SetAttributes(attribute.Int("rpc.attempts", attempt+1))
+			if err != nil && !errors.Is(err, ethereum.NotFound) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+
+		span.AddEvent("retry", trace.WithAttributes(attribute.String("error", err.Error())))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > b.options.MaxBackoff {
+			backoff = b.options.MaxBackoff
+		}
+	}
+}
+
+// callOutcome labels the result of an rpc call, a not found answer is not a failure of the call
+func callOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ethereum.NotFound):
+		return "not_found"
+	case Retryable(err):
+		return "transient_error"
+	default:
+		return "error"
+	}
+}
+
+// WaitForReceipt returns the receipt of the transaction, providers behind a load balancer may not know a just mined
+// transaction yet so a receipt that is not found is asked again until ReceiptGrace passed
+func (b *WrappedBackend) WaitForReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	deadline := time.Now().Add(b.options.ReceiptGrace)
+	backoff := b.options.InitialBackoff
+	for {
+		receipt, err := b.TransactionReceipt(ctx, txHash)
+		if !errors.Is(err, ethereum.NotFound) || time.Now().Add(backoff).After(deadline) {
+			return receipt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > b.options.MaxBackoff {
+			backoff = b.options.MaxBackoff
+		}
 	}
 }
 
 func (b *WrappedBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
-	receipt, err := b.backend.TransactionReceipt(ctx, txHash)
+	var receipt *types.Receipt
+	err := b.retry(ctx, "TransactionReceipt", func(ctx context.Context) error {
+		var err error
+		receipt, err = b.backend.TransactionReceipt(ctx, txHash)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tx receipt:%w", err)
 	}
@@ -33,15 +194,28 @@ func (b *WrappedBackend) TransactionReceipt(ctx context.Context, txHash common.H
 }
 
 func (b *WrappedBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
-	tx, isPending, err := b.backend.TransactionByHash(ctx, hash)
+	var (
+		tx        *types.Transaction
+		isPending bool
+	)
+	err := b.retry(ctx, "TransactionByHash", func(ctx context.Context) error {
+		var err error
+		tx, isPending, err = b.backend.TransactionByHash(ctx, hash)
+		return err
+	})
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to get tx by hash:%w", err)
 	}
-	return tx, isPending, err
+	return tx, isPending, nil
 }
 
 func (b *WrappedBackend) BlockNumber(ctx context.Context) (uint64, error) {
-	blockNumber, err := b.backend.BlockNumber(ctx)
+	var blockNumber uint64
+	err := b.retry(ctx, "BlockNumber", func(ctx context.Context) error {
+		var err error
+		blockNumber, err = b.backend.BlockNumber(ctx)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get block number:%w", err)
 	}
@@ -49,7 +223,12 @@ func (b *WrappedBackend) BlockNumber(ctx context.Context) (uint64, error) {
 }
 
 func (b *WrappedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
-	header, err := b.backend.HeaderByNumber(ctx, number)
+	var header *types.Header
+	err := b.retry(ctx, "HeaderByNumber", func(ctx context.Context) error {
+		var err error
+		header, err = b.backend.HeaderByNumber(ctx, number)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get header by number:%w", err)
 	}
@@ -57,8 +236,12 @@ func (b *WrappedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*
 }
 
 func (b *WrappedBackend) BalanceAt(ctx context.Context, address common.Address, block *big.Int) (*big.Int, error) {
-
-	balance, err := b.backend.BalanceAt(ctx, address, block)
+	var balance *big.Int
+	err := b.retry(ctx, "BalanceAt", func(ctx context.Context) error {
+		var err error
+		balance, err = b.backend.BalanceAt(ctx, address, block)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance at:%w", err)
 	}
@@ -66,8 +249,12 @@ func (b *WrappedBackend) BalanceAt(ctx context.Context, address common.Address,
 }
 
 func (b *WrappedBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
-
-	nonce, err := b.backend.NonceAt(ctx, account, blockNumber)
+	var nonce uint64
+	err := b.retry(ctx, "NonceAt", func(ctx context.Context) error {
+		var err error
+		nonce, err = b.backend.NonceAt(ctx, account, blockNumber)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get nonce at:%w", err)
 	}
@@ -75,8 +262,12 @@ func (b *WrappedBackend) NonceAt(ctx context.Context, account common.Address, bl
 }
 
 func (b *WrappedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
-
-	code, err := b.backend.CodeAt(ctx, contract, blockNumber)
+	var code []byte
+	err := b.retry(ctx, "CodeAt", func(ctx context.Context) error {
+		var err error
+		code, err = b.backend.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get code at:%w", err)
 	}
@@ -84,8 +275,12 @@ func (b *WrappedBackend) CodeAt(ctx context.Context, contract common.Address, bl
 }
 
 func (b *WrappedBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
-
-	result, err := b.backend.CallContract(ctx, call, blockNumber)
+	var result []byte
+	err := b.retry(ctx, "CallContract", func(ctx context.Context) error {
+		var err error
+		result, err = b.backend.CallContract(ctx, call, blockNumber)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to call contract:%w", err)
 	}
@@ -93,7 +288,12 @@ func (b *WrappedBackend) CallContract(ctx context.Context, call ethereum.CallMsg
 }
 
 func (b *WrappedBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
-	nonce, err := b.backend.PendingNonceAt(ctx, account)
+	var nonce uint64
+	err := b.retry(ctx, "PendingNonceAt", func(ctx context.Context) error {
+		var err error
+		nonce, err = b.backend.PendingNonceAt(ctx, account)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get pending nonce:%w", err)
 	}
@@ -101,7 +301,12 @@ func (b *WrappedBackend) PendingNonceAt(ctx context.Context, account common.Addr
 }
 
 func (b *WrappedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
-	gasPrice, err := b.backend.SuggestGasPrice(ctx)
+	var gasPrice *big.Int
+	err := b.retry(ctx, "SuggestGasPrice", func(ctx context.Context) error {
+		var err error
+		gasPrice, err = b.backend.SuggestGasPrice(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get suggested gas price:%w", err)
 	}
@@ -109,23 +314,42 @@ func (b *WrappedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error)
 }
 
 func (b *WrappedBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
-	gasTipCap, err := b.backend.SuggestGasTipCap(ctx)
+	var gasTipCap *big.Int
+	err := b.retry(ctx, "SuggestGasTipCap", func(ctx context.Context) error {
+		var err error
+		gasTipCap, err = b.backend.SuggestGasTipCap(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get suggested tip:%w", err)
 	}
 	return gasTipCap, nil
 }
 
-func (b *WrappedBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
-	gas, err = b.backend.EstimateGas(ctx, call)
+func (b *WrappedBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	var gas uint64
+	err := b.retry(ctx, "EstimateGas", func(ctx context.Context) error {
+		var err error
+		gas, err = b.backend.EstimateGas(ctx, call)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get gas estimate:%w", err)
 	}
 	return gas, nil
 }
 
+// SendTransaction sends the transaction, a retried send that finds the transaction already known succeeded before
 func (b *WrappedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	err := b.backend.SendTransaction(ctx, tx)
+	attempt := 0
+	err := b.retry(ctx, "SendTransaction", func(ctx context.Context) error {
+		attempt++
+		err := b.backend.SendTransaction(ctx, tx)
+		if err != nil && attempt > 1 && strings.Contains(err.Error(), "already known") {
+			return nil
+		}
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to send tx:%w", err)
 	}
@@ -133,7 +357,12 @@ func (b *WrappedBackend) SendTransaction(ctx context.Context, tx *types.Transact
 }
 
 func (b *WrappedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
-	logs, err := b.backend.FilterLogs(ctx, query)
+	var logs []types.Log
+	err := b.retry(ctx, "FilterLogs", func(ctx context.Context) error {
+		var err error
+		logs, err = b.backend.FilterLogs(ctx, query)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to filter logs:%w", err)
 	}
@@ -141,7 +370,12 @@ func (b *WrappedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQu
 }
 
 func (b *WrappedBackend) ChainID(ctx context.Context) (*big.Int, error) {
-	chainID, err := b.backend.ChainID(ctx)
+	var chainID *big.Int
+	err := b.retry(ctx, "ChainID", func(ctx context.Context) error {
+		var err error
+		chainID, err = b.backend.ChainID(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chainID:%w", err)
 	}
@@ -0,0 +1,175 @@
+package transaction_test
+
+import (
+	"context"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gryd-database/platform-poc/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+// flakyBackend fails with the queued errors before answering, any other call panics on the nil embedded backend
+type flakyBackend struct {
+	transaction.Backend
+
+	errs  []error
+	calls int
+}
+
+func (f *flakyBackend) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := f.next(); err != nil {
+		return 0, err
+	}
+	return 100, nil
+}
+
+func (f *flakyBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &types.Receipt{TxHash: txHash}, nil
+}
+
+func (f *flakyBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return f.next()
+}
+
+// rpcError is an error answered by the node
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string {
+	return e.message
+}
+
+func (e rpcError) ErrorCode() int {
+	return e.code
+}
+
+func TestRetryable(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "connection reset", err: syscall.ECONNRESET, retryable: true},
+		{name: "timeout", err: context.DeadlineExceeded, retryable: true},
+		{name: "too many requests", err: rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, retryable: true},
+		{name: "bad gateway", err: rpc.HTTPError{StatusCode: http.StatusBadGateway}, retryable: true},
+		{name: "limit exceeded", err: rpcError{code: -32005, message: "limit exceeded"}, retryable: true},
+		{name: "unauthorized", err: rpc.HTTPError{StatusCode: http.StatusUnauthorized}, retryable: false},
+		{name: "reverted", err: rpcError{code: 3, message: "execution reverted"}, retryable: false},
+		{name: "not found", err: ethereum.NotFound, retryable: false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if transaction.Retryable(tc.err) != tc.retryable {
+				t.Fatalf("expected retryable %v for %v", tc.retryable, tc.err)
+			}
+		})
+	}
+}
+
+func TestWrappedBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	options := transaction.RetryOptions{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{syscall.ECONNRESET, rpc.HTTPError{StatusCode: http.StatusTooManyRequests}}}
+
+		block, err := transaction.NewBackend(backend, options).BlockNumber(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block != 100 || backend.calls != 3 {
+			t.Fatalf("unexpected block %d after %d calls", block, backend.calls)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{syscall.ECONNRESET, syscall.ECONNRESET, syscall.ECONNRESET}}
+
+		_, err := transaction.NewBackend(backend, options).BlockNumber(ctx)
+		if !errors.Is(err, syscall.ECONNRESET) || backend.calls != 3 {
+			t.Fatalf("unexpected error %v after %d calls", err, backend.calls)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{rpcError{code: 3, message: "execution reverted"}}}
+
+		_, err := transaction.NewBackend(backend, options).BlockNumber(ctx)
+		if err == nil || backend.calls != 1 {
+			t.Fatalf("unexpected error %v after %d calls", err, backend.calls)
+		}
+	})
+
+	t.Run("already known after retry", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{syscall.ECONNRESET, rpcError{code: -32000, message: "already known"}}}
+
+		err := transaction.NewBackend(backend, options).SendTransaction(ctx, types.NewTx(&types.DynamicFeeTx{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("receipt within grace", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{ethereum.NotFound, ethereum.NotFound}}
+		wrapped := transaction.NewBackend(backend, transaction.RetryOptions{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, ReceiptGrace: time.Second})
+
+		receipt, err := wrapped.WaitForReceipt(ctx, common.HexToHash("0x01"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.TxHash != common.HexToHash("0x01") || backend.calls != 3 {
+			t.Fatalf("unexpected receipt after %d calls", backend.calls)
+		}
+	})
+
+	t.Run("receipt without grace", func(t *testing.T) {
+		t.Parallel()
+
+		backend := &flakyBackend{errs: []error{ethereum.NotFound}}
+
+		_, err := transaction.NewBackend(backend, options).WaitForReceipt(ctx, common.HexToHash("0x01"))
+		if !errors.Is(err, ethereum.NotFound) || backend.calls != 1 {
+			t.Fatalf("unexpected error %v after %d calls", err, backend.calls)
+		}
+	})
+}